@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/selection"
+	"log"
+	"net/http"
+)
+
+/**
+ * GET    /admin/teams                       - every team with its tasks, as written in the configuration
+ * GET    /admin/teams/payments-zeus         - the tasks of a team
+ * POST   /admin/teams/payments-zeus         - add a team, the body maps task names to tasks
+ * PUT    /admin/teams/payments-zeus         - replace a team
+ * DELETE /admin/teams/payments-zeus         - remove a team
+ */
+func TeamsAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/teams", "team", nil, configs.TeamsSection)
+}
+
+/**
+ * /admin/teams/payments-zeus/tasks/daily - same operations on the tasks of a team, the body is a task
+ */
+func TasksAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/teams/:team/tasks", "task", []string{"team"}, configs.TasksSection)
+}
+
+/**
+ * /admin/groups/payments-support - same operations on the groups, the body is a group
+ */
+func GroupsAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/groups", "group", nil, configs.GroupsSection)
+}
+
+/**
+ * /admin/groups/payments-support/teams/payments-zeus-backend - same operations on the teams of a group
+ */
+func GroupTeamsAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/groups/:group/teams", "team", []string{"group"}, configs.GroupTeamsSection)
+}
+
+/**
+ * /admin/messages/daily - same operations on the messages, the body is a JSON string
+ */
+func MessagesAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/messages", "message", nil, configs.MessagesSection)
+}
+
+/**
+ * /admin/members/pedro87silva - same operations on the members registry, the body is a member
+ */
+func MembersAdminApi(r *gin.Engine) gin.IRoutes {
+	return configEntriesApi(r, "/admin/members", "member", nil, configs.MembersSection)
+}
+
+// configEntriesApi registers the list, get, create, replace and delete endpoints of a section of
+// the configuration. Every change is validated, written back to the configuration file, recorded
+// as a new configuration version and applied to the scheduler right away.
+func configEntriesApi[V any](r *gin.Engine, path string, param string, parents []string, section configs.ConfigSection[V]) gin.IRoutes {
+	routes := r.Group(path)
+	entryPath := "/:" + param
+	parentNames := func(c *gin.Context) []string {
+		names := make([]string, 0, len(parents))
+		for _, parent := range parents {
+			names = append(names, c.Param(parent))
+		}
+		return names
+	}
+
+	routes.GET("", requireScope(models.ScopeRead), func(c *gin.Context) {
+		entries, err := configs.GetConfigEntries(section, parentNames(c))
+		if err != nil {
+			configErrorResponse(c, []error{err})
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	})
+
+	routes.GET(entryPath, requireScope(models.ScopeRead), func(c *gin.Context) {
+		entry, err := configs.GetConfigEntry(section, parentNames(c), c.Param(param))
+		if err != nil {
+			configErrorResponse(c, []error{err})
+			return
+		}
+
+		c.JSON(http.StatusOK, entry)
+	})
+
+	routes.POST(entryPath, requireScope(models.ScopeWrite), func(c *gin.Context) {
+		entry, ok := bindConfigEntry[V](c)
+		if !ok {
+			return
+		}
+
+		snapshot, errs := configs.CreateConfigEntry(section, parentNames(c), c.Param(param), entry, configAuthor(c))
+		configChangeResponse(c, http.StatusCreated, snapshot, errs)
+	})
+
+	routes.PUT(entryPath, requireScope(models.ScopeWrite), func(c *gin.Context) {
+		entry, ok := bindConfigEntry[V](c)
+		if !ok {
+			return
+		}
+
+		snapshot, errs := configs.ReplaceConfigEntry(section, parentNames(c), c.Param(param), entry, configAuthor(c))
+		configChangeResponse(c, http.StatusOK, snapshot, errs)
+	})
+
+	return routes.DELETE(entryPath, requireScope(models.ScopeWrite), func(c *gin.Context) {
+		snapshot, errs := configs.DeleteConfigEntry(section, parentNames(c), c.Param(param), configAuthor(c))
+		configChangeResponse(c, http.StatusOK, snapshot, errs)
+	})
+}
+
+func bindConfigEntry[V any](c *gin.Context) (V, bool) {
+	var entry V
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body: " + err.Error()})
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// configAuthor is the name of the API key a change was made with. The ?author= of the request is
+// only kept as a note, it cannot be verified.
+func configAuthor(c *gin.Context) models.ConfigAuthor {
+	return models.ConfigAuthor{Name: c.GetString(apiKeyName), Note: c.Query("author")}
+}
+
+func configChangeResponse(c *gin.Context, status int, snapshot models.ConfigSnapshot, errs []error) {
+	if len(errs) > 0 {
+		configErrorResponse(c, errs)
+		return
+	}
+	selection.ReloadScheduler()
+
+	c.JSON(status, configVersion{
+		Version:   snapshot.Version,
+		Timestamp: snapshot.Timestamp,
+		Author:    snapshot.Author,
+		Note:      snapshot.Note,
+		Source:    snapshot.Source,
+		Diff:      snapshot.Diff,
+	})
+}
+
+// configErrorResponse answers 404 for unknown entries, 409 for existing ones and 422 when the
+// change would leave the configuration invalid.
+func configErrorResponse(c *gin.Context, errs []error) {
+	problems := make([]string, 0, len(errs))
+	for _, err := range errs {
+		problems = append(problems, err.Error())
+	}
+
+	if len(errs) == 1 && errors.Is(errs[0], configs.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": problems[0]})
+		return
+	}
+
+	if len(errs) == 1 && errors.Is(errs[0], configs.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": problems[0]})
+		return
+	}
+
+	log.Println("Rejected configuration change:", problems)
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid configuration change", "problems": problems})
+}
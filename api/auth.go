@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"io"
+	"io.mt-borring.bot/configs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// apiKeyName is the context key holding the name of the API key a request was authenticated with.
+const apiKeyName = "apiKeyName"
+
+// requireScope only lets through the requests carrying an API key, from API_KEYS or
+// ADMIN_API_TOKEN, that allows the scope. The REST API is disabled while no key is set.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKeys := configs.GetAPIKeys()
+		if len(apiKeys) == 0 {
+			abortWithAuthError(c, http.StatusForbidden, "the REST API is disabled, set API_KEYS or ADMIN_API_TOKEN to enable it")
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || provided == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="mr-boring"`)
+			abortWithAuthError(c, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		for _, apiKey := range apiKeys {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey.Key)) != 1 {
+				continue
+			}
+
+			if !apiKey.Allows(scope) {
+				abortWithAuthError(c, http.StatusForbidden, "the API key "+apiKey.Name+" lacks the "+scope+" scope")
+				return
+			}
+
+			c.Set(apiKeyName, apiKey.Name)
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Bearer realm="mr-boring"`)
+		abortWithAuthError(c, http.StatusUnauthorized, "invalid bearer token")
+	}
+}
+
+// requireSlackRequest only lets through the slash commands sent by Slack. With SLACK_SIGNING_SECRET
+// the v0 signature of the request is verified and requests older than five minutes are rejected as
+// replays, otherwise the legacy verification token in SLACK_VERIFICATION_TOKEN is compared. Slash
+// commands are disabled while neither is set.
+func requireSlackRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret := os.Getenv("SLACK_SIGNING_SECRET"); secret != "" {
+			verifySlackSignature(c, secret)
+			return
+		}
+
+		token := os.Getenv("SLACK_VERIFICATION_TOKEN")
+		if token == "" {
+			abortWithAuthError(c, http.StatusForbidden, "slash commands are disabled, set SLACK_SIGNING_SECRET to enable them")
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(c.PostForm("token")), []byte(token)) != 1 {
+			abortWithAuthError(c, http.StatusUnauthorized, "the request was not sent by Slack")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// verifySlackSignature checks X-Slack-Signature against the raw body, which is put back in place
+// so the handlers can still bind the form.
+func verifySlackSignature(c *gin.Context, secret string) {
+	verifier, err := slack.NewSecretsVerifier(c.Request.Header, secret)
+	if err != nil {
+		abortWithAuthError(c, http.StatusUnauthorized, "invalid Slack request: "+err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithAuthError(c, http.StatusBadRequest, "unreadable request body")
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Set(gin.BodyBytesKey, body)
+
+	_, _ = verifier.Write(body)
+	if err := verifier.Ensure(); err != nil {
+		abortWithAuthError(c, http.StatusUnauthorized, "invalid Slack signature")
+		return
+	}
+
+	c.Next()
+}
+
+// abortWithAuthError answers every authentication and authorization failure the same way.
+func abortWithAuthError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message, "status": status})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"io.mt-borring.bot/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func signSlackRequest(secret string, timestamp time.Time, body string) (string, string) {
+	seconds := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + seconds + ":" + body))
+
+	return seconds, "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := url.Values{
+		"token":        {"legacy-token"},
+		"team_id":      {"T123"},
+		"team_domain":  {"payments"},
+		"channel_id":   {"C123"},
+		"channel_name": {"payments-zeus"},
+		"user_id":      {"U123"},
+		"user_name":    {"pedro87silva"},
+		"command":      {"/boring"},
+		"text":         {"help"},
+		"response_url": {"https://hooks.slack.com/commands/T123/1/abc"},
+	}.Encode()
+
+	tests := []struct {
+		name       string
+		secret     string
+		signedAt   time.Time
+		sentBody   string
+		wantStatus int
+	}{
+		{name: "valid signature", secret: testSigningSecret, signedAt: time.Now(), sentBody: body, wantStatus: http.StatusOK},
+		{name: "tampered body", secret: testSigningSecret, signedAt: time.Now(), sentBody: strings.Replace(body, "help", "rotate", 1), wantStatus: http.StatusUnauthorized},
+		{name: "wrong secret", secret: "another-secret", signedAt: time.Now(), sentBody: body, wantStatus: http.StatusUnauthorized},
+		{name: "timestamp older than five minutes", secret: testSigningSecret, signedAt: time.Now().Add(-6 * time.Minute), sentBody: body, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("SLACK_SIGNING_SECRET", testSigningSecret)
+
+			var bound models.SlackCommand
+			r := gin.New()
+			r.POST("/boring", requireSlackRequest(), func(c *gin.Context) {
+				if err := c.ShouldBind(&bound); err != nil {
+					c.String(http.StatusBadRequest, err.Error())
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			timestamp, signature := signSlackRequest(test.secret, test.signedAt, body)
+			request := httptest.NewRequest(http.MethodPost, "/boring", strings.NewReader(test.sentBody))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+			request.Header.Set("X-Slack-Signature", signature)
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.wantStatus, recorder.Body.String())
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+
+			if bound.Command != "/boring" || bound.Text != "help" || bound.UserID != "U123" {
+				t.Errorf("the form was not bound after the verification: %+v", bound)
+			}
+		})
+	}
+}
+
+func TestVerifySlackSignatureRestoresBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SLACK_SIGNING_SECRET", testSigningSecret)
+	body := url.Values{"command": {"/boring"}, "text": {"help"}}.Encode()
+
+	var cachedBody any
+	var restoredBody []byte
+	r := gin.New()
+	r.POST("/boring", requireSlackRequest(), func(c *gin.Context) {
+		cachedBody, _ = c.Get(gin.BodyBytesKey)
+		restoredBody, _ = io.ReadAll(c.Request.Body)
+		c.Status(http.StatusOK)
+	})
+
+	timestamp, signature := signSlackRequest(testSigningSecret, time.Now(), body)
+	request := httptest.NewRequest(http.MethodPost, "/boring", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	request.Header.Set("X-Slack-Signature", signature)
+	r.ServeHTTP(httptest.NewRecorder(), request)
+
+	if cached, ok := cachedBody.([]byte); !ok || string(cached) != body {
+		t.Errorf("gin.BodyBytesKey holds %v, want %q", cachedBody, body)
+	}
+	if string(restoredBody) != body {
+		t.Errorf("request body %q, want %q", restoredBody, body)
+	}
+}
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/selection"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subcommand is one of the /boring subcommands. Run returns the response type and text of the
+// answer, and usage errors are answered with the help of the subcommand.
+type subcommand struct {
+	name    string
+	summary string
+	usage   []string
+	run     func(command models.SlackCommand, arguments []string) (string, string)
+}
+
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{
+			name:    "replace",
+			summary: "replace a selected member with someone else",
+			usage:   []string{"/boring replace <member> in teams <team> <task>", "/boring replace <member> in groups <group> <team>"},
+			run:     replaceCommand,
+		},
+		{
+			name:    "skip",
+			summary: "hand a selected member's turn over and keep them out until the next cycle",
+			usage:   []string{"/boring skip <member> in teams <team> <task>", "/boring skip <member> in groups <group> <team>"},
+			run:     skipCommand,
+		},
+		{
+			name:    "show",
+			summary: "list the selected or available members, or the latest events of a rotation",
+			usage:   []string{"/boring show selected|available|history teams <team> <task>", "/boring show selected|available|history groups <group> <team>"},
+			run:     showCommand,
+		},
+		{
+			name:    "why",
+			summary: "explain the last selection of a rotation",
+			usage:   []string{"/boring why teams <team> <task>", "/boring why groups <group> <team>"},
+			run:     whyCommand,
+		},
+		{
+			name:    "rotate",
+			summary: "select the next members of a rotation right now, leads and admins only",
+			usage:   []string{"/boring rotate teams <team> <task>", "/boring rotate groups <group>"},
+			run:     rotateCommand,
+		},
+		{
+			name:    "pause",
+			summary: "skip the selections of a rotation until a day or until resumed, leads and admins only",
+			usage:   []string{"/boring pause teams <team> <task> [until YYYY-MM-DD] [reason]", "/boring pause groups <group> [until YYYY-MM-DD] [reason]"},
+			run:     pauseCommand,
+		},
+		{
+			name:    "resume",
+			summary: "resume a paused rotation, leads and admins only",
+			usage:   []string{"/boring resume teams <team> <task>", "/boring resume groups <group>"},
+			run:     resumeCommand,
+		},
+		{
+			name:    "config",
+			summary: "list the configuration versions or roll back to one, admins only",
+			usage:   []string{"/boring config versions", "/boring config rollback <version>"},
+			run:     configCommand,
+		},
+		{
+			name:    "help",
+			summary: "describe the commands",
+			usage:   []string{"/boring help [command]"},
+			run:     helpCommand,
+		},
+	}
+}
+
+/**
+ * /boring help                                       - every command
+ * /boring replace @pedro87silva in teams payments-zeus support
+ * /boring skip @pedro87silva in teams payments-zeus daily
+ * /boring show selected teams payments-zeus support
+ * /boring rotate teams payments-zeus daily
+ * /boring pause groups payments-support until 2026-12-31 holidays
+ * /boring config rollback 3
+ */
+func BoringCommandApi(r *gin.Engine) gin.IRoutes {
+	return r.POST("/boring", requireSlackRequest(), func(c *gin.Context) {
+		var command models.SlackCommand
+		if err := c.ShouldBind(&command); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Println("Text :: " + command.Text)
+		log.Println("Command :: " + command.Command)
+
+		arguments := tokenize(command.Text)
+		name := "help"
+		if len(arguments) > 0 {
+			name = strings.ToLower(arguments[0])
+			arguments = arguments[1:]
+		}
+
+		responseType, text := runSubcommand(name, command, arguments)
+		c.JSON(http.StatusOK, gin.H{
+			"response_type": responseType,
+			"text":          text,
+		})
+	})
+}
+
+func runSubcommand(name string, command models.SlackCommand, arguments []string) (string, string) {
+	for _, sub := range subcommands {
+		if sub.name == name {
+			return sub.run(command, arguments)
+		}
+	}
+
+	return "ephemeral", "Unknown command `" + name + "`.\n" + describeSubcommands()
+}
+
+// usageError answers a command that could not be parsed with the help of the subcommand.
+func usageError(name string, problem string) (string, string) {
+	for _, sub := range subcommands {
+		if sub.name == name {
+			return "ephemeral", strings.ToUpper(problem[:1]) + problem[1:] + ".\n" + describeSubcommand(sub)
+		}
+	}
+
+	return "ephemeral", problem
+}
+
+func unexpectedArguments(name string, rest []string) (string, string) {
+	return usageError(name, "Unexpected `"+strings.Join(rest, " ")+"`")
+}
+
+func helpCommand(command models.SlackCommand, arguments []string) (string, string) {
+	if len(arguments) > 0 {
+		for _, sub := range subcommands {
+			if sub.name == strings.ToLower(arguments[0]) {
+				return "ephemeral", describeSubcommand(sub)
+			}
+		}
+	}
+
+	return "ephemeral", describeSubcommands()
+}
+
+func describeSubcommands() string {
+	lines := []string{"Available commands:"}
+	for _, sub := range subcommands {
+		lines = append(lines, fmt.Sprintf("• `%s` - %s", sub.name, sub.summary))
+	}
+	lines = append(lines, "Run `/boring help <command>` for its usage. Members can be mentioned, e.g. `@pedro87silva`.")
+
+	return strings.Join(lines, "\n")
+}
+
+func describeSubcommand(sub subcommand) string {
+	lines := []string{"`" + sub.name + "` - " + sub.summary + ". Usage:"}
+	for _, usage := range sub.usage {
+		lines = append(lines, "• `"+usage+"`")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func replaceCommand(command models.SlackCommand, arguments []string) (string, string) {
+	return handOverCommand("replace", command, arguments)
+}
+
+// skipCommand hands the turn of a member over like replaceCommand, and keeps the member out of the
+// rest of the cycle.
+func skipCommand(command models.SlackCommand, arguments []string) (string, string) {
+	return handOverCommand("skip", command, arguments)
+}
+
+// handOverCommand parses `<member> in <rotation>` and replaces or skips the member. Members may only
+// hand over their own turn, leads and admins the turn of anyone in their rotations.
+func handOverCommand(name string, command models.SlackCommand, arguments []string) (string, string) {
+	if len(arguments) == 0 {
+		return usageError(name, "Missing the member to "+name)
+	}
+
+	member := arguments[0]
+	arguments = arguments[1:]
+	if len(arguments) > 0 && arguments[0] == "in" {
+		arguments = arguments[1:]
+	}
+
+	rotation, rest, err := parseRotation(arguments, true)
+	if err != nil {
+		return usageError(name, err.Error())
+	}
+	if len(rest) > 0 {
+		return unexpectedArguments(name, rest)
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+	if err := validateMember(rotation, member); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	if err := configs.CheckRotationPermission(command.UserID, command.UserName, rotation, member); err != nil {
+		return "ephemeral", "Permission denied: " + err.Error()
+	}
+
+	trigger := models.Trigger{Source: models.TriggerSlash, User: command.UserID}
+	if name == "skip" {
+		newMember, err := selection.SkipMember(rotation, member, trigger)
+		if err != nil {
+			return "ephemeral", fmt.Sprintf("Could not skip %s in %s: %v", member, strings.TrimSpace(rotation.String()), err)
+		}
+
+		log.Println("New Member :: " + newMember)
+		return "in_channel", fmt.Sprintf("%s skips until the next cycle. It's your turn, %s", member, configs.Mention(newMember))
+	}
+
+	newMember, err := replaceUser(member, rotation.Type, rotation.Name, rotation.Task, trigger)
+	if err != nil {
+		return "ephemeral", fmt.Sprintf("Could not replace %s in %s: %v", member, strings.TrimSpace(rotation.String()), err)
+	}
+
+	log.Println("New Member :: " + newMember)
+	return "in_channel", "It's your turn, " + configs.Mention(newMember)
+}
+
+func showCommand(command models.SlackCommand, arguments []string) (string, string) {
+	if len(arguments) == 0 || !slices.Contains([]string{"selected", "available", "history"}, arguments[0]) {
+		return usageError("show", "Expected `selected`, `available` or `history`")
+	}
+
+	operationType := arguments[0]
+	rotation, rest, err := parseRotation(arguments[1:], true)
+	if err != nil {
+		return usageError("show", err.Error())
+	}
+	if len(rest) > 0 {
+		return unexpectedArguments("show", rest)
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	if "history" == operationType {
+		text, found := showHistory(rotation.Type, rotation.Name, rotation.Task)
+		if !found {
+			return "ephemeral", text
+		}
+		return "in_channel", text
+	}
+
+	users := showUsers(operationType, rotation.Type, rotation.Name, rotation.Task)
+	ineligibleUsers := showIneligibleUsers(operationType, rotation.Type, rotation.Name, rotation.Task)
+	log.Println("Users :: " + strings.Join(users, ", "))
+
+	if len(users) == 0 {
+		text := "Nobody has been selected in " + strings.TrimSpace(rotation.String()) + " yet"
+		if "available" == operationType {
+			text = "Nobody in " + strings.TrimSpace(rotation.String()) + " can be selected right now"
+		}
+		if len(ineligibleUsers) > 0 {
+			text += "\nIneligible: " + strings.Join(ineligibleUsers, ", ")
+		}
+		return "ephemeral", text
+	}
+
+	text := strings.Join(users, ", ")
+	if len(ineligibleUsers) > 0 {
+		text += "\nIneligible: " + strings.Join(ineligibleUsers, ", ")
+	}
+	return "in_channel", text
+}
+
+func whyCommand(command models.SlackCommand, arguments []string) (string, string) {
+	rotation, rest, err := parseRotation(arguments, true)
+	if err != nil {
+		return usageError("why", err.Error())
+	}
+	if len(rest) > 0 {
+		return unexpectedArguments("why", rest)
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	explanation, ok := configs.GetLastSelection(rotation)
+	if !ok {
+		return "ephemeral", "Nobody has been selected for " + rotation.String() + " yet"
+	}
+
+	return "in_channel", selection.Describe(explanation)
+}
+
+func rotateCommand(command models.SlackCommand, arguments []string) (string, string) {
+	rotation, rest, err := parseRotation(arguments, false)
+	if err != nil {
+		return usageError("rotate", err.Error())
+	}
+	if len(rest) > 0 {
+		return unexpectedArguments("rotate", rest)
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	if err := configs.CheckRotationPermission(command.UserID, command.UserName, rotation, ""); err != nil {
+		return "ephemeral", "Permission denied: " + err.Error()
+	}
+
+	trigger := models.Trigger{Source: models.TriggerSlash, User: command.UserID}
+	if rotation.Type == "teams" {
+		err = selection.SelectUserForTask(rotation.Name, rotation.Task, trigger)
+	} else {
+		err = selection.SelectUsersForSupport(rotation.Name, configs.GetGeneralConfiguration().Groups[rotation.Name], trigger)
+	}
+	if err != nil {
+		return "ephemeral", fmt.Sprintf("No new selection for %s: %v", strings.TrimSpace(rotation.String()), err)
+	}
+
+	return "in_channel", fmt.Sprintf("<@%s> started a new selection for %s", command.UserID, strings.TrimSpace(rotation.String()))
+}
+
+func pauseCommand(command models.SlackCommand, arguments []string) (string, string) {
+	rotation, rest, err := parseRotation(arguments, false)
+	if err != nil {
+		return usageError("pause", err.Error())
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	pause := models.Pause{Rotation: rotation}
+	if len(rest) > 0 && rest[0] == "until" {
+		if len(rest) < 2 {
+			return usageError("pause", "Missing the day after `until`")
+		}
+		day, err := time.ParseInLocation("2006-01-02", rest[1], time.Local)
+		if err != nil {
+			return usageError("pause", "Invalid day `"+rest[1]+"`, expected YYYY-MM-DD")
+		}
+		// The pause lasts the whole day
+		pause.Until = day.AddDate(0, 0, 1)
+		rest = rest[2:]
+	}
+	pause.Reason = strings.Join(rest, " ")
+
+	if err := configs.CheckRotationPermission(command.UserID, command.UserName, rotation, ""); err != nil {
+		return "ephemeral", "Permission denied: " + err.Error()
+	}
+
+	if err := configs.AddPause(pause); err != nil {
+		log.Println("Error writing pauses:", err)
+		return "ephemeral", fmt.Sprintf("Could not pause %s, try again: %v", strings.TrimSpace(rotation.String()), err)
+	}
+
+	text := fmt.Sprintf("<@%s> paused %s", command.UserID, strings.TrimSpace(rotation.String()))
+	if !pause.Until.IsZero() {
+		text += " until " + lastPausedDay(pause.Until)
+	}
+	if pause.Reason != "" {
+		text += " (" + pause.Reason + ")"
+	}
+	return "in_channel", text
+}
+
+// lastPausedDay is the last day of a pause lasting until the start of the given day.
+func lastPausedDay(until time.Time) string {
+	return until.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+func resumeCommand(command models.SlackCommand, arguments []string) (string, string) {
+	rotation, rest, err := parseRotation(arguments, false)
+	if err != nil {
+		return usageError("resume", err.Error())
+	}
+	if len(rest) > 0 {
+		return unexpectedArguments("resume", rest)
+	}
+	if err := validateRotation(rotation); err != nil {
+		return "ephemeral", err.Error()
+	}
+
+	if err := configs.CheckRotationPermission(command.UserID, command.UserName, rotation, ""); err != nil {
+		return "ephemeral", "Permission denied: " + err.Error()
+	}
+
+	if _, paused := configs.GetPause(rotation, time.Now()); !paused {
+		return "ephemeral", strings.TrimSpace(rotation.String()) + " is not paused"
+	}
+	if err := configs.RemovePause(rotation); err != nil {
+		log.Println("Error writing pauses:", err)
+		return "ephemeral", fmt.Sprintf("Could not resume %s, try again: %v", strings.TrimSpace(rotation.String()), err)
+	}
+
+	return "in_channel", fmt.Sprintf("<@%s> resumed %s", command.UserID, strings.TrimSpace(rotation.String()))
+}
+
+func configCommand(command models.SlackCommand, arguments []string) (string, string) {
+	if len(arguments) == 1 && arguments[0] == "versions" {
+		snapshots := configs.GetConfigSnapshots()
+		if len(snapshots) == 0 {
+			return "ephemeral", "No configuration versions recorded yet"
+		}
+
+		lines := []string{"Configuration versions:"}
+		for _, snapshot := range snapshots[max(0, len(snapshots)-10):] {
+			lines = append(lines, fmt.Sprintf("• v%d %s by %s (%s, %d change(s))",
+				snapshot.Version, snapshot.Timestamp.Format("2006-01-02 15:04"), snapshot.Author, snapshot.Source, len(snapshot.Diff)))
+		}
+		return "ephemeral", strings.Join(lines, "\n")
+	}
+
+	if len(arguments) != 2 || arguments[0] != "rollback" {
+		return usageError("config", "Expected `versions` or `rollback <version>`")
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(arguments[1], "v"))
+	if err != nil {
+		return usageError("config", "Invalid version `"+arguments[1]+"`")
+	}
+
+	if !configs.IsAdmin(command.UserID) {
+		return "ephemeral", "Permission denied: only admins can roll the configuration back"
+	}
+
+	author := configs.GetCallingMember(command.UserID, command.UserName)
+
+	snapshot, err := configs.RollbackConfiguration(version, models.ConfigAuthor{Name: author})
+	if err != nil {
+		log.Println("Error rolling back the configuration:", err)
+		return "ephemeral", "Could not roll back to version " + arguments[1] + ": " + err.Error()
+	}
+	selection.ReloadScheduler()
+
+	return "in_channel", fmt.Sprintf("%s rolled the configuration back to version %d, now version %d (%d change(s))",
+		configs.Mention(author), version, snapshot.Version, len(snapshot.Diff))
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/utils"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// mentionPattern matches the user mentions Slack sends in slash commands, <@U123> or <@U123|name>.
+var mentionPattern = regexp.MustCompile(`^<@([A-Z0-9]+)(?:\|([^>]*))?>$`)
+
+// tokenize splits the text of a slash command into its words, turning user mentions into member
+// keys: the registry key of the Slack user, otherwise the username of the mention.
+func tokenize(text string) []string {
+	arguments := strings.Fields(text)
+	for i, argument := range arguments {
+		arguments[i] = parseMember(argument)
+	}
+
+	return arguments
+}
+
+// parseMember returns the member key of <@U123|name>, <@U123>, @name or name.
+func parseMember(argument string) string {
+	match := mentionPattern.FindStringSubmatch(argument)
+	if match == nil {
+		return strings.TrimPrefix(argument, "@")
+	}
+
+	if member, ok := configs.GetMemberBySlackID(match[1]); ok {
+		return member
+	}
+	if match[2] != "" {
+		return match[2]
+	}
+
+	return match[1]
+}
+
+// parseRotation reads "teams <team> <task>" or "groups <group> <team>" from the arguments and
+// returns the arguments left. Without groupTeam a group rotation is the group as a whole, read as
+// "groups <group>".
+func parseRotation(arguments []string, groupTeam bool) (models.RotationKey, []string, error) {
+	if len(arguments) < 2 || (arguments[0] != "teams" && arguments[0] != "groups") {
+		return models.RotationKey{}, nil, errors.New("expected `teams <team> <task>` or `groups <group> <team>`")
+	}
+
+	rotation := models.RotationKey{Type: arguments[0], Name: arguments[1]}
+	rest := arguments[2:]
+	if rotation.Type == "groups" && !groupTeam {
+		return rotation, rest, nil
+	}
+
+	if len(rest) == 0 {
+		if rotation.Type == "teams" {
+			return models.RotationKey{}, nil, errors.New("missing the task of team " + rotation.Name)
+		}
+		return models.RotationKey{}, nil, errors.New("missing the team of group " + rotation.Name)
+	}
+	rotation.Task = rest[0]
+
+	return rotation, rest[1:], nil
+}
+
+// validateRotation tells which team, task, group or group team of the rotation is not configured,
+// suggesting the closest configured names.
+func validateRotation(rotation models.RotationKey) error {
+	generalConfiguration := configs.GetGeneralConfiguration()
+
+	if rotation.Type == "teams" {
+		taskMap, ok := generalConfiguration.Teams[rotation.Name]
+		if !ok {
+			return unknownName("team", rotation.Name, names(generalConfiguration.Teams))
+		}
+		if _, ok := taskMap[rotation.Task]; !ok {
+			return unknownName("task of team "+rotation.Name, rotation.Task, names(taskMap))
+		}
+		return nil
+	}
+
+	supportDefinition, ok := generalConfiguration.Groups[rotation.Name]
+	if !ok {
+		return unknownName("group", rotation.Name, names(generalConfiguration.Groups))
+	}
+	if _, ok := supportDefinition.Teams[rotation.Task]; rotation.Task != "" && !ok {
+		return unknownName("team of group "+rotation.Name, rotation.Task, names(supportDefinition.Teams))
+	}
+
+	return nil
+}
+
+// validateMember tells when the member is not part of the rotation, suggesting the closest members.
+func validateMember(rotation models.RotationKey, member string) error {
+	generalConfiguration := configs.GetGeneralConfiguration()
+	members := generalConfiguration.Teams[rotation.Name][rotation.Task].Members
+	if rotation.Type == "groups" {
+		members = generalConfiguration.Groups[rotation.Name].Teams[rotation.Task].Members
+	}
+
+	if slices.Contains(members, member) {
+		return nil
+	}
+
+	return unknownName("member of "+strings.TrimSpace(rotation.String()), member, members)
+}
+
+func names[V any](entries map[string]V) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func unknownName(kind string, name string, candidates []string) error {
+	slices.Sort(candidates)
+	if matches := utils.CloseMatches(name, candidates); len(matches) > 0 {
+		return fmt.Errorf("`%s` is not a %s. Did you mean %s?", name, kind, quoteNames(matches, " or "))
+	}
+	if len(candidates) > 0 && len(candidates) <= 10 {
+		return fmt.Errorf("`%s` is not a %s, expected one of %s", name, kind, quoteNames(candidates, ", "))
+	}
+
+	return fmt.Errorf("`%s` is not a %s", name, kind)
+}
+
+func quoteNames(names []string, separator string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, "`"+name+"`")
+	}
+
+	return strings.Join(quoted, separator)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/selection"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// configVersion is a snapshot without its definition, to keep the list of versions short.
+type configVersion struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author"`
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"`
+	Diff      []string  `json:"diff"`
+}
+
+/**
+ * GET /admin/config/versions - every version of the configuration with its author and diff
+ */
+func ConfigVersionsApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/admin/config/versions", requireScope(models.ScopeRead), func(c *gin.Context) {
+		versions := []configVersion{}
+		for _, snapshot := range configs.GetConfigSnapshots() {
+			versions = append(versions, configVersion{
+				Version:   snapshot.Version,
+				Timestamp: snapshot.Timestamp,
+				Author:    snapshot.Author,
+				Note:      snapshot.Note,
+				Source:    snapshot.Source,
+				Diff:      snapshot.Diff,
+			})
+		}
+
+		c.JSON(http.StatusOK, versions)
+	})
+}
+
+/**
+ * GET /admin/config/versions/3 - version 3 of the configuration, including the whole definition
+ */
+func ConfigVersionApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/admin/config/versions/:version", requireScope(models.ScopeRead), func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version " + c.Param("version")})
+			return
+		}
+
+		snapshot, ok := configs.GetConfigSnapshot(version)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown configuration version " + c.Param("version")})
+			return
+		}
+
+		c.JSON(http.StatusOK, snapshot)
+	})
+}
+
+/**
+ * POST /admin/config/rollback/3?author=pedro87silva - restore version 3 of the configuration and
+ * reschedule the rotations. The rollback is recorded as a new version.
+ */
+func ConfigRollbackApi(r *gin.Engine) gin.IRoutes {
+	return r.POST("/admin/config/rollback/:version", requireScope(models.ScopeAdmin), func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version " + c.Param("version")})
+			return
+		}
+
+		snapshot, err := configs.RollbackConfiguration(version, configAuthor(c))
+		if err != nil {
+			log.Println("Error rolling back the configuration:", err)
+			configErrorResponse(c, []error{err})
+			return
+		}
+		selection.ReloadScheduler()
+
+		c.JSON(http.StatusOK, snapshot)
+	})
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/selection"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * /why teams payments-zeus daily
+ * /why groups payments-support payments-zeus-backend
+ */
+func WhyApi(r *gin.Engine) gin.IRoutes {
+	return r.POST("/why", requireSlackRequest(), func(c *gin.Context) {
+		var command models.SimpleSlackCommand
+		if err := c.ShouldBind(&command); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Println("Text :: " + command.Text)
+		log.Println("Command :: " + command.Command)
+
+		responseType, text := whyCommand(models.SlackCommand{Command: command.Command, Text: command.Text}, tokenize(command.Text))
+		c.JSON(http.StatusOK, gin.H{
+			"response_type": responseType,
+			"text":          text,
+		})
+	})
+}
+
+/**
+ * GET /history?type=teams&name=payments-zeus&task=daily&event=selection&from=2026-10-01&to=2026-10-31
+ */
+func HistoryApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/history", requireScope(models.ScopeRead), func(c *gin.Context) {
+		filter, err := historyFilter(c)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"history": configs.GetHistory(filter),
+		})
+	})
+}
+
+/**
+ * GET /history/export?format=csv&type=teams&name=payments-zeus&from=2026-10-01
+ * Accepts the same filters as /history, the format is jsonl (default) or csv.
+ */
+func ExportHistoryApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/history/export", requireScope(models.ScopeRead), func(c *gin.Context) {
+		filter, err := historyFilter(c)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		history := configs.GetHistory(filter)
+		switch c.DefaultQuery("format", "jsonl") {
+		case "jsonl":
+			c.Header("Content-Disposition", `attachment; filename="history.jsonl"`)
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "application/x-ndjson")
+			encoder := json.NewEncoder(c.Writer)
+			for _, event := range history {
+				if err := encoder.Encode(event); err != nil {
+					log.Println("Error exporting history:", err)
+					return
+				}
+			}
+		case "csv":
+			c.Header("Content-Disposition", `attachment; filename="history.csv"`)
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "text/csv")
+			writer := csv.NewWriter(c.Writer)
+			_ = writer.Write([]string{"timestamp", "event", "type", "name", "task", "members", "trigger", "triggeredBy", "messageTs", "reason"})
+			for _, event := range history {
+				_ = writer.Write([]string{
+					event.Timestamp.Format(time.RFC3339),
+					event.Type,
+					event.Rotation.Type,
+					event.Rotation.Name,
+					event.Rotation.Task,
+					strings.Join(event.Members, ";"),
+					event.Trigger,
+					event.TriggeredBy,
+					event.MessageTS,
+					event.Reason,
+				})
+			}
+			writer.Flush()
+		default:
+			c.JSON(400, gin.H{"error": "format must be jsonl or csv"})
+		}
+	})
+}
+
+func historyFilter(c *gin.Context) (configs.HistoryFilter, error) {
+	filter := configs.HistoryFilter{
+		Rotation: models.RotationKey{
+			Type: c.Query("type"),
+			Name: c.Query("name"),
+			Task: c.Query("task"),
+		},
+		Type: c.Query("event"),
+	}
+
+	var err error
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.ParseInLocation(time.DateOnly, from, time.Local); err != nil {
+			return filter, fmt.Errorf("from must be a date like 2026-10-01")
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.ParseInLocation(time.DateOnly, to, time.Local); err != nil {
+			return filter, fmt.Errorf("to must be a date like 2026-10-31")
+		}
+		// Include the whole last day
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+
+	return filter, nil
+}
+
+// showHistory describes the latest events of a rotation, and tells whether there were any
+func showHistory(teamType string, teamOrGroup string, teamMeeting string) (string, bool) {
+	history := configs.GetHistory(configs.HistoryFilter{Rotation: models.RotationKey{Type: teamType, Name: teamOrGroup, Task: teamMeeting}})
+	if len(history) == 0 {
+		return "Nothing happened in " + teamType + " " + teamOrGroup + " " + teamMeeting + " yet", false
+	}
+
+	if len(history) > 10 {
+		history = history[len(history)-10:]
+	}
+
+	lines := make([]string, 0, len(history))
+	for _, event := range history {
+		line := event.Timestamp.Format("2006-01-02 15:04") + " " + event.Type
+		if len(event.Members) > 0 {
+			line += ": " + strings.Join(event.Members, ", ")
+		}
+		if event.Reason != "" {
+			line += " (" + event.Reason + ")"
+		}
+		line += " by " + event.Trigger
+		if event.TriggeredBy != "" {
+			line += " <@" + event.TriggeredBy + ">"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n"), true
+}
+
+/**
+ * GET /history/replay?type=teams&name=payments-zeus&task=daily&seed=5577006791947779410
+ */
+func ReplayApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/history/replay", requireScope(models.ScopeRead), func(c *gin.Context) {
+		seed, err := strconv.ParseInt(c.Query("seed"), 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "seed must be a number"})
+			return
+		}
+
+		rotation := models.RotationKey{
+			Type: c.Query("type"),
+			Name: c.Query("name"),
+			Task: c.Query("task"),
+		}
+
+		for _, explanation := range configs.GetSelectionHistory(rotation) {
+			if explanation.Seed != seed {
+				continue
+			}
+
+			replayed := selection.Replay(explanation)
+			c.JSON(http.StatusOK, gin.H{
+				"recorded": explanation,
+				"replayed": replayed,
+				"matches":  slices.Equal(replayed, explanation.Selected),
+			})
+			return
+		}
+
+		c.JSON(404, gin.H{"error": "no selection found with that seed"})
+	})
+}
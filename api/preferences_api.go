@@ -0,0 +1,166 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/selection"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+/**
+ * /prefs                          - show the preferences of the calling member, found in the members
+ *                                   registry by Slack user ID, otherwise by Slack username
+ * /prefs exclude wednesday        - never select me on wednesdays
+ * /prefs prefer afternoon friday  - select me on friday afternoons when possible
+ * /prefs clear                    - remove the preferences set through this command
+ * /prefs away 2026-10-20 2026-10-24 - out of office between both days, inclusive
+ * /prefs back                     - forget every out of office period
+ */
+func PreferencesApi(r *gin.Engine) gin.IRoutes {
+	return r.POST("/prefs", requireSlackRequest(), func(c *gin.Context) {
+		var command models.SlackCommand
+		if err := c.ShouldBind(&command); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		log.Println("Text :: " + command.Text)
+		log.Println("Command :: " + command.Command)
+
+		text := updatePreferences(configs.GetCallingMember(command.UserID, command.UserName), strings.Fields(strings.ToLower(command.Text)))
+		c.JSON(http.StatusOK, gin.H{
+			"response_type": "ephemeral",
+			"text":          text,
+		})
+	})
+}
+
+func updatePreferences(member string, arguments []string) string {
+	if len(arguments) == 0 || arguments[0] == "show" {
+		return describePreferences(member, configs.GetMemberPreferences(member))
+	}
+
+	if arguments[0] == "clear" {
+		if err := configs.ClearStoredMemberPreferences(member); err != nil {
+			return preferencesNotSaved(err)
+		}
+		return describePreferences(member, configs.GetMemberPreferences(member))
+	}
+
+	if arguments[0] == "away" {
+		return updateAwayPeriods(member, arguments[1:])
+	}
+
+	if arguments[0] == "back" {
+		if err := configs.ClearAwayPeriods(member); err != nil {
+			return availabilityNotSaved(err)
+		}
+		return describePreferences(member, configs.GetMemberPreferences(member))
+	}
+
+	if arguments[0] != "exclude" && arguments[0] != "prefer" {
+		return "Usage: /prefs [show|clear|exclude <days/shifts>|prefer <days/shifts>|away <from> [to]|back]"
+	}
+
+	if len(arguments) == 1 {
+		return "Tell me which days (monday..sunday) or shifts (morning, afternoon) to " + arguments[0]
+	}
+
+	var days []string
+	var shifts []string
+	for _, value := range arguments[1:] {
+		value = strings.TrimSuffix(value, "s")
+		if slices.Contains(weekdays, value) {
+			days = append(days, value)
+			continue
+		}
+
+		if value == selection.ShiftMorning || value == selection.ShiftAfternoon {
+			shifts = append(shifts, value)
+			continue
+		}
+
+		return "Unknown day or shift: " + value
+	}
+
+	preferences := configs.GetStoredMemberPreferences(member)
+	if arguments[0] == "exclude" {
+		preferences.ExcludedDays = append(preferences.ExcludedDays, days...)
+		preferences.ExcludedShifts = append(preferences.ExcludedShifts, shifts...)
+	} else {
+		preferences.PreferredDays = append(preferences.PreferredDays, days...)
+		preferences.PreferredShifts = append(preferences.PreferredShifts, shifts...)
+	}
+	if err := configs.SetStoredMemberPreferences(member, preferences); err != nil {
+		return preferencesNotSaved(err)
+	}
+
+	return describePreferences(member, configs.GetMemberPreferences(member))
+}
+
+func updateAwayPeriods(member string, arguments []string) string {
+	if len(arguments) == 0 || len(arguments) > 2 {
+		return "Usage: /prefs away <from> [to], with dates like 2026-10-20"
+	}
+
+	from, err := time.ParseInLocation(time.DateOnly, arguments[0], time.Local)
+	if err != nil {
+		return "Invalid date: " + arguments[0]
+	}
+
+	to := from
+	if len(arguments) == 2 {
+		to, err = time.ParseInLocation(time.DateOnly, arguments[1], time.Local)
+		if err != nil {
+			return "Invalid date: " + arguments[1]
+		}
+	}
+
+	if to.Before(from) {
+		return "The end of the out of office period must not be before its start"
+	}
+
+	if err := configs.AddAwayPeriod(member, models.AwayPeriod{From: from, To: to.AddDate(0, 0, 1)}); err != nil {
+		return availabilityNotSaved(err)
+	}
+	return describePreferences(member, configs.GetMemberPreferences(member))
+}
+
+func preferencesNotSaved(err error) string {
+	log.Println("Error writing member preferences:", err)
+	return "Could not save your preferences, try again: " + err.Error()
+}
+
+func availabilityNotSaved(err error) string {
+	log.Println("Error writing availability:", err)
+	return "Could not save your out of office periods, try again: " + err.Error()
+}
+
+func describePreferences(member string, preferences models.MemberPreferences) string {
+	var builder strings.Builder
+	builder.WriteString("Preferences for " + member + "\n")
+	builder.WriteString("Never on: " + describePreferenceValues(preferences.ExcludedDays, preferences.ExcludedShifts) + "\n")
+	builder.WriteString("Preferably on: " + describePreferenceValues(preferences.PreferredDays, preferences.PreferredShifts))
+
+	for _, period := range configs.GetAwayPeriods(member) {
+		builder.WriteString("\nOut of office: " + period.From.Format(time.DateOnly) + " to " + period.To.AddDate(0, 0, -1).Format(time.DateOnly))
+	}
+
+	return builder.String()
+}
+
+func describePreferenceValues(days []string, shifts []string) string {
+	values := append(append([]string{}, days...), shifts...)
+	if len(values) == 0 {
+		return "-"
+	}
+
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/storage"
+	"log"
+	"net/http"
+)
+
+/**
+ * GET /admin/state/export              - the whole state in one document
+ * GET /admin/state/export?team=payments-zeus - only the selections, pauses and history of a team
+ */
+func ExportStateApi(r *gin.Engine) gin.IRoutes {
+	return r.GET("/admin/state/export", requireScope(models.ScopeAdmin), func(c *gin.Context) {
+		document, err := configs.ExportState(c.Query("team"))
+		if err != nil {
+			log.Println("Error exporting state:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, document)
+	})
+}
+
+/**
+ * POST /admin/state/import?mode=merge|replace&team=payments-zeus with an exported document as body.
+ * Documents exported by older versions are migrated first. The document is validated against the
+ * configuration and nothing is imported when it is invalid.
+ */
+func ImportStateApi(r *gin.Engine) gin.IRoutes {
+	return r.POST("/admin/state/import", requireScope(models.ScopeAdmin), func(c *gin.Context) {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state document: " + err.Error()})
+			return
+		}
+
+		document, err := storage.DecodeStateDocument(data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state document: " + err.Error()})
+			return
+		}
+
+		summary, errs := configs.ImportState(document, configs.ImportOptions{
+			Mode: c.DefaultQuery("mode", configs.ImportMerge),
+			Team: c.Query("team"),
+		})
+		if len(errs) > 0 {
+			problems := make([]string, 0, len(errs))
+			for _, err := range errs {
+				problems = append(problems, err.Error())
+			}
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid state document", "problems": problems})
+			return
+		}
+
+		c.JSON(http.StatusOK, summary)
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/storage"
+	"os"
+)
+
+// runCommand runs the command line subcommand given in the arguments and returns its exit code.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "validate":
+		return validate(args[1:])
+	case "convert":
+		return convert(args[1:])
+	case "reconcile":
+		return reconcile(args[1:])
+	case "export":
+		return exportState(args[1:])
+	case "import":
+		return importState(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown command:", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring [-config <path>] [-data-dir <dir>] [validate <file> | convert <from> <to> | reconcile [--dry-run] | export [--team <team>] [file] | import [--mode merge|replace] [--team <team>] <file>]")
+		return 2
+	}
+}
+
+// validate checks a configuration file before it is merged, e.g. mr-boring validate configuration.json
+func validate(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring validate <file>")
+		return 2
+	}
+
+	errs := configs.ValidateConfigurationFile(args[0])
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%s has %d problem(s)\n", args[0], len(errs))
+		return 1
+	}
+
+	fmt.Println(args[0], "is valid")
+	return 0
+}
+
+// convert translates a configuration file between formats, detected by extension, e.g.
+// mr-boring convert configuration.json configuration.yaml
+func convert(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring convert <from> <to>")
+		return 2
+	}
+
+	err := configs.ConvertConfigurationFile(args[0], args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error converting configuration:", err)
+		return 1
+	}
+
+	fmt.Println("Converted", args[0], "to", args[1])
+	return 0
+}
+
+// reconcile drops the stored state that no longer matches the configuration and prints what
+// changed, or what would change with --dry-run.
+func reconcile(args []string) int {
+	dryRun := len(args) == 1 && args[0] == "--dry-run"
+	if len(args) > 1 || (len(args) == 1 && !dryRun) {
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring reconcile [--dry-run]")
+		return 2
+	}
+
+	if dryRun {
+		configs.LoadConfigurationAndStateReadOnly()
+	} else {
+		configs.LoadConfigurationAndState()
+	}
+	changes, err := configs.ReconcileState(dryRun)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reconciling the stored state:", err)
+		return 1
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("The stored state matches the configuration")
+	}
+	return 0
+}
+
+// exportState writes the whole state, or a team's, to a file or the standard output.
+func exportState(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	team := flags.String("team", "", "only export the selections, pauses and history of this team")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring export [--team <team>] [file]")
+		return 2
+	}
+
+	configs.LoadConfigurationAndStateReadOnly()
+	document, err := configs.ExportState(*team)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error exporting state:", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error exporting state:", err)
+		return 1
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Println(string(data))
+		return 0
+	}
+
+	err = os.WriteFile(flags.Arg(0), append(data, '\n'), 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error exporting state:", err)
+		return 1
+	}
+
+	fmt.Println("Exported state to", flags.Arg(0))
+	return 0
+}
+
+// importState validates a state document against the configuration and imports it. Stop the
+// bot first, or use the admin API, so a running bot does not overwrite the imported state.
+func importState(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	mode := flags.String("mode", configs.ImportMerge, "merge keeps the entries the document does not mention, replace drops them")
+	team := flags.String("team", "", "only import the selections, pauses and history of this team")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mr-boring import [--mode merge|replace] [--team <team>] <file>")
+		return 2
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error importing state:", err)
+		return 1
+	}
+
+	document, err := storage.DecodeStateDocument(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid state document:", err)
+		return 1
+	}
+
+	configs.LoadConfigurationAndState()
+	summary, errs := configs.ImportState(document, configs.ImportOptions{Mode: *mode, Team: *team})
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+
+	fmt.Printf("Imported %d tasks, %d groups, %d preferences, %d availability, %d pauses and %d history events (%d already recorded)\n",
+		summary.Tasks, summary.Groups, summary.Preferences, summary.Availability, summary.Pauses, summary.HistoryEvents, summary.SkippedHistory)
+	return 0
+}
@@ -0,0 +1,176 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("already exists")
+)
+
+// configurationMutex serializes the changes written back to the configuration file.
+var configurationMutex sync.Mutex
+
+// ConfigSection locates the entries of one kind in the general definition as written, e.g. the
+// tasks of a team. Parents holds the names of the enclosing entries, the team for its tasks.
+type ConfigSection[V any] func(written *models.GeneralDefinition, parents []string) (map[string]V, error)
+
+var TeamsSection ConfigSection[map[string]models.Task] = func(written *models.GeneralDefinition, parents []string) (map[string]map[string]models.Task, error) {
+	if written.Teams == nil {
+		written.Teams = make(map[string]map[string]models.Task)
+	}
+	return written.Teams, nil
+}
+
+var TasksSection ConfigSection[models.Task] = func(written *models.GeneralDefinition, parents []string) (map[string]models.Task, error) {
+	tasks, ok := written.Teams[parents[0]]
+	if !ok {
+		return nil, fmt.Errorf("team %s: %w", parents[0], ErrNotFound)
+	}
+	if tasks == nil {
+		tasks = make(map[string]models.Task)
+		written.Teams[parents[0]] = tasks
+	}
+	return tasks, nil
+}
+
+var GroupsSection ConfigSection[models.SupportDefinition] = func(written *models.GeneralDefinition, parents []string) (map[string]models.SupportDefinition, error) {
+	if written.Groups == nil {
+		written.Groups = make(map[string]models.SupportDefinition)
+	}
+	return written.Groups, nil
+}
+
+var GroupTeamsSection ConfigSection[models.TeamDefinition] = func(written *models.GeneralDefinition, parents []string) (map[string]models.TeamDefinition, error) {
+	supportDefinition, ok := written.Groups[parents[0]]
+	if !ok {
+		return nil, fmt.Errorf("group %s: %w", parents[0], ErrNotFound)
+	}
+	if supportDefinition.Teams == nil {
+		supportDefinition.Teams = make(map[string]models.TeamDefinition)
+		written.Groups[parents[0]] = supportDefinition
+	}
+	return supportDefinition.Teams, nil
+}
+
+var MessagesSection ConfigSection[string] = func(written *models.GeneralDefinition, parents []string) (map[string]string, error) {
+	if written.Messages == nil {
+		written.Messages = make(map[string]string)
+	}
+	return written.Messages, nil
+}
+
+var MembersSection ConfigSection[models.Member] = func(written *models.GeneralDefinition, parents []string) (map[string]models.Member, error) {
+	if written.Members == nil {
+		written.Members = make(map[string]models.Member)
+	}
+	return written.Members, nil
+}
+
+// GetConfigEntries returns the entries of a section as written in the configuration file.
+func GetConfigEntries[V any](section ConfigSection[V], parents []string) (map[string]V, error) {
+	written, errs := readConfiguration(configurationFile())
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return section(&written, parents)
+}
+
+func GetConfigEntry[V any](section ConfigSection[V], parents []string, name string) (V, error) {
+	var entry V
+	entries, err := GetConfigEntries(section, parents)
+	if err != nil {
+		return entry, err
+	}
+
+	entry, ok := entries[name]
+	if !ok {
+		return entry, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+
+	return entry, nil
+}
+
+// CreateConfigEntry adds an entry to a section, failing with ErrConflict when it already exists.
+func CreateConfigEntry[V any](section ConfigSection[V], parents []string, name string, entry V, author models.ConfigAuthor) (models.ConfigSnapshot, []error) {
+	return updateConfigEntries(section, parents, author, func(entries map[string]V) error {
+		if _, ok := entries[name]; ok {
+			return fmt.Errorf("%s: %w", name, ErrConflict)
+		}
+		entries[name] = entry
+		return nil
+	}, name)
+}
+
+// ReplaceConfigEntry replaces an entry of a section, failing with ErrNotFound when it does not exist.
+func ReplaceConfigEntry[V any](section ConfigSection[V], parents []string, name string, entry V, author models.ConfigAuthor) (models.ConfigSnapshot, []error) {
+	return updateConfigEntries(section, parents, author, func(entries map[string]V) error {
+		if _, ok := entries[name]; !ok {
+			return fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		entries[name] = entry
+		return nil
+	}, name)
+}
+
+func DeleteConfigEntry[V any](section ConfigSection[V], parents []string, name string, author models.ConfigAuthor) (models.ConfigSnapshot, []error) {
+	return updateConfigEntries(section, parents, author, func(entries map[string]V) error {
+		if _, ok := entries[name]; !ok {
+			return fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		delete(entries, name)
+		return nil
+	}, name)
+}
+
+func updateConfigEntries[V any](section ConfigSection[V], parents []string, author models.ConfigAuthor, update func(entries map[string]V) error, name string) (models.ConfigSnapshot, []error) {
+	if strings.TrimSpace(name) == "" || strings.TrimSpace(name) != name {
+		return models.ConfigSnapshot{}, []error{fmt.Errorf("name %q: must not be empty or start or end with spaces", name)}
+	}
+
+	return UpdateConfiguration(author, func(written *models.GeneralDefinition) error {
+		entries, err := section(written, parents)
+		if err != nil {
+			return err
+		}
+		return update(entries)
+	})
+}
+
+// UpdateConfiguration changes the general definition as written, validates the result, writes it
+// back to the configuration file and applies it. Nothing is written when the update fails or the
+// result is invalid. The scheduler has to be reloaded by the caller.
+func UpdateConfiguration(author models.ConfigAuthor, update func(written *models.GeneralDefinition) error) (models.ConfigSnapshot, []error) {
+	configurationMutex.Lock()
+	defer configurationMutex.Unlock()
+
+	path := configurationFile()
+	written, errs := readConfiguration(path)
+	if len(errs) > 0 {
+		return models.ConfigSnapshot{}, errs
+	}
+
+	if err := update(&written); err != nil {
+		return models.ConfigSnapshot{}, []error{err}
+	}
+
+	generalConfiguration, errs := prepareGeneralDefinition(written)
+	if len(errs) > 0 {
+		return models.ConfigSnapshot{}, errs
+	}
+
+	if err := writeConfigurationFile(path, written); err != nil {
+		return models.ConfigSnapshot{}, []error{err}
+	}
+
+	snapshot := recordConfigSnapshot(written, author, models.SnapshotAPI)
+	applyGeneralDefinition(generalConfiguration)
+
+	return snapshot, nil
+}
@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// GetAPIKeys returns the keys of the REST API, read once from API_KEYS as comma separated
+// name=key:scope entries, e.g. "portal=s3cr3t:write,grafana=t0k3n:read". ADMIN_API_TOKEN is kept
+// as the key of the "admin" client with the admin scope.
+var GetAPIKeys = sync.OnceValue(func() []models.APIKey {
+	var apiKeys []models.APIKey
+
+	for _, entry := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rest, _ := strings.Cut(entry, "=")
+		key, scope, _ := strings.Cut(rest, ":")
+		if name == "" || key == "" || !slices.Contains([]string{models.ScopeRead, models.ScopeWrite, models.ScopeAdmin}, scope) {
+			log.Printf("Ignoring API key %q, expected name=key:read|write|admin", name)
+			continue
+		}
+
+		apiKeys = append(apiKeys, models.APIKey{Name: name, Key: key, Scope: scope})
+	}
+
+	if token := os.Getenv("ADMIN_API_TOKEN"); token != "" {
+		apiKeys = append(apiKeys, models.APIKey{Name: "admin", Key: token, Scope: models.ScopeAdmin})
+	}
+
+	return apiKeys
+})
@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"slices"
+	"time"
+)
+
+// GetAwayPeriod returns the period the member is out of office at the given time, if any.
+func GetAwayPeriod(member string, at time.Time) (models.AwayPeriod, bool) {
+	for _, period := range GetAwayPeriods(member) {
+		if !at.Before(period.From) && at.Before(period.To) {
+			return period, true
+		}
+	}
+
+	return models.AwayPeriod{}, false
+}
+
+func GetAwayPeriods(member string) []models.AwayPeriod {
+	return State().Availability().Members[member]
+}
+
+// AddAwayPeriod records an out of office period and forgets the ones that already ended.
+func AddAwayPeriod(member string, period models.AwayPeriod) error {
+	now := time.Now()
+	return State().UpdateAvailability(func(availability *models.AvailabilityStorage) error {
+		periods := slices.DeleteFunc(availability.Members[member], func(existing models.AwayPeriod) bool {
+			return existing.To.Before(now)
+		})
+		availability.Members[member] = append(periods, period)
+		return nil
+	})
+}
+
+func ClearAwayPeriods(member string) error {
+	return State().UpdateAvailability(func(availability *models.AvailabilityStorage) error {
+		delete(availability.Members, member)
+		return nil
+	})
+}
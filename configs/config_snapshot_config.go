@@ -0,0 +1,198 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/storage"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetConfigSnapshots returns every recorded version of the general definition, oldest first.
+func GetConfigSnapshots() []models.ConfigSnapshot {
+	snapshots, err := backend.ReadConfigSnapshots()
+	if err != nil {
+		log.Println("Error reading config snapshots:", err)
+	}
+
+	return snapshots
+}
+
+func GetConfigSnapshot(version int) (models.ConfigSnapshot, bool) {
+	for _, snapshot := range GetConfigSnapshots() {
+		if snapshot.Version == version {
+			return snapshot, true
+		}
+	}
+
+	return models.ConfigSnapshot{}, false
+}
+
+// recordConfigSnapshot keeps a new version of the general definition as written, together with
+// its author and the differences with the previous version. Nothing is recorded when the
+// definition did not change.
+func recordConfigSnapshot(written models.GeneralDefinition, author models.ConfigAuthor, source string) models.ConfigSnapshot {
+	snapshots := GetConfigSnapshots()
+
+	var previous models.GeneralDefinition
+	version := 1
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		previous = latest.Definition
+		version = latest.Version + 1
+	}
+
+	diff := diffGeneralDefinitions(previous, written)
+	if len(snapshots) > 0 && len(diff) == 0 {
+		return snapshots[len(snapshots)-1]
+	}
+
+	snapshot := models.ConfigSnapshot{
+		Version:    version,
+		Timestamp:  time.Now(),
+		Author:     author.Name,
+		Note:       author.Note,
+		Source:     source,
+		Diff:       diff,
+		Definition: written,
+	}
+
+	if err := backend.AppendConfigSnapshot(snapshot); err != nil {
+		log.Println("Error recording config snapshot:", err)
+		return snapshot
+	}
+
+	log.Printf("Recorded configuration version %d (%s by %s, %d change(s))", version, source, author.Name, len(diff))
+	return snapshot
+}
+
+// RollbackConfiguration writes a previous version of the general definition back to the
+// configuration file and applies it. The scheduler has to be reloaded by the caller.
+func RollbackConfiguration(version int, author models.ConfigAuthor) (models.ConfigSnapshot, error) {
+	configurationMutex.Lock()
+	defer configurationMutex.Unlock()
+
+	snapshot, ok := GetConfigSnapshot(version)
+	if !ok {
+		return models.ConfigSnapshot{}, fmt.Errorf("configuration version %d: %w", version, ErrNotFound)
+	}
+
+	generalConfiguration, errs := prepareGeneralDefinition(snapshot.Definition)
+	if len(errs) > 0 {
+		return models.ConfigSnapshot{}, fmt.Errorf("configuration version %d is not valid anymore: %w", version, errors.Join(errs...))
+	}
+
+	if err := writeConfigurationFile(configurationFile(), snapshot.Definition); err != nil {
+		return models.ConfigSnapshot{}, err
+	}
+
+	recorded := recordConfigSnapshot(snapshot.Definition, author, models.SnapshotRollback)
+	applyGeneralDefinition(generalConfiguration)
+
+	return recorded, nil
+}
+
+// applyGeneralDefinition swaps the general definition in use and brings the state in line with it.
+func applyGeneralDefinition(generalConfiguration models.GeneralDefinition) {
+	setGeneralDefinition(generalConfiguration)
+	reconcileStateOnLoad(os.Getenv("RECONCILE_DRY_RUN") == "true")
+	ApplyMembershipChanges()
+}
+
+// writeConfigurationFile replaces the configuration file, in its own format, keeping the previous
+// version as a backup. A configuration directory is written back file by file.
+func writeConfigurationFile(path string, written models.GeneralDefinition) error {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return writeConfigurationDirectory(path, written)
+	}
+
+	format, err := configurationFormat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeGeneralDefinition(format, written)
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFileAtomically(path, data)
+}
+
+// diffGeneralDefinitions lists the values added (+), removed (-) and changed (~) between two
+// versions of the general definition, by path.
+func diffGeneralDefinitions(previous models.GeneralDefinition, current models.GeneralDefinition) []string {
+	previousValues := flattenDefinition(previous)
+	currentValues := flattenDefinition(current)
+
+	var diff []string
+	for path, value := range currentValues {
+		previousValue, ok := previousValues[path]
+		if !ok {
+			diff = append(diff, fmt.Sprintf("+ %s: %s", path, shorten(value)))
+		} else if previousValue != value {
+			diff = append(diff, fmt.Sprintf("~ %s: %s -> %s", path, shorten(previousValue), shorten(value)))
+		}
+	}
+
+	for path, value := range previousValues {
+		if _, ok := currentValues[path]; !ok {
+			diff = append(diff, fmt.Sprintf("- %s: %s", path, shorten(value)))
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+
+	return diff
+}
+
+// flattenDefinition maps every leaf of the definition to its path, lists are kept whole.
+func flattenDefinition(generalConfiguration models.GeneralDefinition) map[string]string {
+	values := make(map[string]string)
+
+	data, err := json.Marshal(generalConfiguration)
+	if err != nil {
+		return values
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document map[string]any
+	if err := decoder.Decode(&document); err != nil {
+		return values
+	}
+
+	var flatten func(path string, value any)
+	flatten = func(path string, value any) {
+		if object, ok := value.(map[string]any); ok {
+			for key, child := range object {
+				flatten(strings.TrimPrefix(path+"."+key, "."), child)
+			}
+			return
+		}
+
+		encoded, _ := json.Marshal(value)
+		values[path] = string(encoded)
+	}
+	flatten("", document)
+
+	return values
+}
+
+func shorten(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 80 {
+		return value
+	}
+
+	return string(runes[:77]) + "..."
+}
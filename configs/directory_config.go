@@ -0,0 +1,269 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/storage"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configurationDirectory holds one general definition file per team or group, used when there is
+// no single configuration file.
+const configurationDirectory = "configuration.d"
+
+// readConfiguration decodes a general definition file, or every file of a configuration directory
+// merged into a single definition.
+func readConfiguration(path string) (models.GeneralDefinition, []error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.GeneralDefinition{}, []error{err}
+	}
+
+	if info.IsDir() {
+		return readConfigurationDirectory(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.GeneralDefinition{}, []error{err}
+	}
+
+	generalConfiguration, err := decodeGeneralDefinition(path, data)
+	if err != nil {
+		return generalConfiguration, []error{err}
+	}
+
+	return generalConfiguration, nil
+}
+
+// readConfigurationDirectory merges the configuration files of a directory in name order. Teams of
+// different files are merged task by task, while any key defined by more than one file is a conflict.
+func readConfigurationDirectory(directory string) (models.GeneralDefinition, []error) {
+	generalConfiguration, _, _, errs := mergeConfigurationDirectory(directory)
+	return generalConfiguration, errs
+}
+
+// mergeConfigurationDirectory merges the configuration files of a directory and returns which file
+// defines every key, together with the definition of every file.
+func mergeConfigurationDirectory(directory string) (models.GeneralDefinition, map[string]string, map[string]models.GeneralDefinition, []error) {
+	generalConfiguration := models.GeneralDefinition{
+		Messages:    make(map[string]string),
+		Teams:       make(map[string]map[string]models.Task),
+		Groups:      make(map[string]models.SupportDefinition),
+		Preferences: make(map[string]models.MemberPreferences),
+		Tags:        make(map[string][]string),
+		Members:     make(map[string]models.Member),
+	}
+	origins := make(map[string]string)
+	files := make(map[string]models.GeneralDefinition)
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return generalConfiguration, origins, files, []error{err}
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(directory, entry.Name())
+		if _, err := configurationFormat(path); err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fileConfiguration, err := decodeGeneralDefinition(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		files[path] = fileConfiguration
+		errs = append(errs, mergeGeneralDefinition(&generalConfiguration, fileConfiguration, path, origins)...)
+	}
+
+	if len(files) == 0 {
+		errs = append(errs, fmt.Errorf("%s: no .json, .yaml, .yml or .toml configuration files", directory))
+	}
+
+	return generalConfiguration, origins, files, errs
+}
+
+func mergeGeneralDefinition(target *models.GeneralDefinition, source models.GeneralDefinition, path string, origins map[string]string) []error {
+	var errs []error
+
+	claim := func(key string) bool {
+		if origin, ok := origins[key]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s is already defined in %s", path, key, origin))
+			return false
+		}
+		origins[key] = path
+		return true
+	}
+
+	if source.DefaultCron != "" && claim("defaultCron") {
+		target.DefaultCron = source.DefaultCron
+	}
+
+	if source.Onboarding != (models.OnboardingPolicy{}) && claim("onboarding") {
+		target.Onboarding = source.Onboarding
+	}
+
+	if (source.Permissions.AdminUsergroup != "" || len(source.Permissions.LeadUsergroups) > 0) && claim("permissions") {
+		target.Permissions = source.Permissions
+	}
+
+	for _, teamName := range sortedKeys(source.Teams) {
+		if target.Teams[teamName] == nil {
+			target.Teams[teamName] = make(map[string]models.Task)
+		}
+		for _, taskName := range sortedKeys(source.Teams[teamName]) {
+			if claim(fmt.Sprintf("teams.%s.%s", teamName, taskName)) {
+				target.Teams[teamName][taskName] = source.Teams[teamName][taskName]
+			}
+		}
+	}
+
+	mergeSection(target.Groups, source.Groups, "groups", claim)
+	mergeSection(target.Messages, source.Messages, "messages", claim)
+	mergeSection(target.Preferences, source.Preferences, "preferences", claim)
+	mergeSection(target.Tags, source.Tags, "tags", claim)
+	mergeSection(target.Members, source.Members, "members", claim)
+
+	return errs
+}
+
+func mergeSection[V any](target map[string]V, source map[string]V, section string, claim func(key string) bool) {
+	for _, key := range sortedKeys(source) {
+		if claim(section + "." + key) {
+			target[key] = source[key]
+		}
+	}
+}
+
+// writeConfigurationDirectory writes a general definition back to the files of a configuration
+// directory, each key to the file defining it. A new task goes to the file of its team, a new team
+// or group to a file named after it and any other new key to the file of its section, or the first
+// file. Only the files whose content changed are written.
+func writeConfigurationDirectory(directory string, written models.GeneralDefinition) error {
+	_, origins, files, errs := mergeConfigurationDirectory(directory)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	paths := sortedKeys(files)
+	fallback := paths[0]
+	// locate returns the file defining the key, otherwise the first file defining a key with the
+	// prefix, otherwise the given file.
+	locate := func(key string, prefix string, otherwise string) string {
+		if origin, ok := origins[key]; ok {
+			return origin
+		}
+		for _, claimed := range sortedKeys(origins) {
+			if strings.HasPrefix(claimed, prefix) {
+				return origins[claimed]
+			}
+		}
+		return otherwise
+	}
+	fileNamedAfter := func(name string) string {
+		if name == "" || strings.HasPrefix(name, ".") || filepath.Base(name) != name {
+			return fallback
+		}
+		return filepath.Join(directory, name+filepath.Ext(fallback))
+	}
+
+	split := make(map[string]*models.GeneralDefinition)
+	fileAt := func(path string) *models.GeneralDefinition {
+		if split[path] == nil {
+			split[path] = &models.GeneralDefinition{}
+		}
+		return split[path]
+	}
+	for _, path := range paths {
+		fileAt(path)
+	}
+
+	if written.DefaultCron != "" {
+		fileAt(locate("defaultCron", "defaultCron", fallback)).DefaultCron = written.DefaultCron
+	}
+	if written.Onboarding != (models.OnboardingPolicy{}) {
+		fileAt(locate("onboarding", "onboarding", fallback)).Onboarding = written.Onboarding
+	}
+	if written.Permissions.AdminUsergroup != "" || len(written.Permissions.LeadUsergroups) > 0 {
+		fileAt(locate("permissions", "permissions", fallback)).Permissions = written.Permissions
+	}
+
+	for teamName, taskMap := range written.Teams {
+		for taskName, task := range taskMap {
+			file := fileAt(locate("teams."+teamName+"."+taskName, "teams."+teamName+".", fileNamedAfter(teamName)))
+			if file.Teams == nil {
+				file.Teams = make(map[string]map[string]models.Task)
+			}
+			if file.Teams[teamName] == nil {
+				file.Teams[teamName] = make(map[string]models.Task)
+			}
+			file.Teams[teamName][taskName] = task
+		}
+	}
+
+	for groupName, group := range written.Groups {
+		file := fileAt(locate("groups."+groupName, "groups."+groupName, fileNamedAfter(groupName)))
+		if file.Groups == nil {
+			file.Groups = make(map[string]models.SupportDefinition)
+		}
+		file.Groups[groupName] = group
+	}
+
+	splitSection(written.Messages, "messages", locate, fallback, fileAt, func(file *models.GeneralDefinition) *map[string]string { return &file.Messages })
+	splitSection(written.Preferences, "preferences", locate, fallback, fileAt, func(file *models.GeneralDefinition) *map[string]models.MemberPreferences { return &file.Preferences })
+	splitSection(written.Tags, "tags", locate, fallback, fileAt, func(file *models.GeneralDefinition) *map[string][]string { return &file.Tags })
+	splitSection(written.Members, "members", locate, fallback, fileAt, func(file *models.GeneralDefinition) *map[string]models.Member { return &file.Members })
+
+	for _, path := range sortedKeys(split) {
+		format, err := configurationFormat(path)
+		if err != nil {
+			return err
+		}
+
+		data, err := encodeGeneralDefinition(format, *split[path])
+		if err != nil {
+			return err
+		}
+
+		if previous, ok := files[path]; ok {
+			previousData, err := encodeGeneralDefinition(format, previous)
+			if err == nil && bytes.Equal(previousData, data) {
+				continue
+			}
+		}
+
+		if err := storage.WriteFileAtomically(path, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func splitSection[V any](entries map[string]V, section string, locate func(key string, prefix string, otherwise string) string, fallback string,
+	fileAt func(path string) *models.GeneralDefinition, sectionOf func(file *models.GeneralDefinition) *map[string]V) {
+	for key, entry := range entries {
+		target := sectionOf(fileAt(locate(section+"."+key, section+".", fallback)))
+		if *target == nil {
+			*target = make(map[string]V)
+		}
+		(*target)[key] = entry
+	}
+}
@@ -0,0 +1,140 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"io.mt-borring.bot/models"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configurationFiles are the general definition files looked up, in order, in the working directory.
+var configurationFiles = []string{"configuration.json", "configuration.yaml", "configuration.yml", "configuration.toml", configurationDirectory}
+
+// configurationFile returns the general definition file or directory given by CONFIG_PATH, or else
+// the first one that exists, configuration.json when there is none so the error names the file
+// most setups use.
+func configurationFile() string {
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
+	}
+
+	for _, path := range configurationFiles {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return configurationFiles[0]
+}
+
+// configurationFormat detects the format of a general definition file from its extension.
+func configurationFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("%s: unknown configuration format, expected a .json, .yaml, .yml or .toml file", path)
+	}
+}
+
+func decodeGeneralDefinition(path string, data []byte) (models.GeneralDefinition, error) {
+	var generalConfiguration models.GeneralDefinition
+
+	format, err := configurationFormat(path)
+	if err != nil {
+		return generalConfiguration, err
+	}
+
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &generalConfiguration)
+		if err != nil {
+			return generalConfiguration, fmt.Errorf("%s: %v", path, err)
+		}
+	case FormatTOML:
+		err = toml.Unmarshal(data, &generalConfiguration)
+		var decodeError *toml.DecodeError
+		if errors.As(err, &decodeError) {
+			line, column := decodeError.Position()
+			return generalConfiguration, fmt.Errorf("%s:%d:%d: %v", path, line, column, err)
+		}
+		if err != nil {
+			return generalConfiguration, fmt.Errorf("%s: %v", path, err)
+		}
+	default:
+		err = json.Unmarshal(data, &generalConfiguration)
+		if err != nil {
+			return generalConfiguration, describeJSONError(path, data, err)
+		}
+	}
+
+	return generalConfiguration, nil
+}
+
+func encodeGeneralDefinition(format string, generalConfiguration models.GeneralDefinition) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	switch format {
+	case FormatYAML:
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(generalConfiguration); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		encoder := toml.NewEncoder(&buffer)
+		encoder.SetIndentTables(true)
+		if err := encoder.Encode(generalConfiguration); err != nil {
+			return nil, err
+		}
+	default:
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(generalConfiguration); err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// ConvertConfigurationFile translates a general definition file to the format of the target file,
+// e.g. configuration.json to configuration.yaml. A configuration directory is merged into one file.
+func ConvertConfigurationFile(source string, target string) error {
+	format, err := configurationFormat(target)
+	if err != nil {
+		return err
+	}
+
+	generalConfiguration, errs := readConfiguration(source)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	converted, err := encodeGeneralDefinition(format, generalConfiguration)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, converted, 0644)
+}
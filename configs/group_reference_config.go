@@ -0,0 +1,104 @@
+package configs
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"slices"
+	"sort"
+)
+
+// resolveGroupReferences fills the members of every group team that references a team, and
+// optionally one of its tasks, from the teams section, keeping only the members with one of
+// the given tags. It returns one error per unknown reference.
+func resolveGroupReferences(generalConfiguration *models.GeneralDefinition) []error {
+	var errs []error
+
+	for _, groupName := range sortedKeys(generalConfiguration.Groups) {
+		supportDefinition := generalConfiguration.Groups[groupName]
+
+		for _, teamName := range sortedKeys(supportDefinition.Teams) {
+			teamDefinition := supportDefinition.Teams[teamName]
+			path := fmt.Sprintf("groups.%s.teams.%s", groupName, teamName)
+
+			members := append([]string{}, teamDefinition.Members...)
+			if teamDefinition.Team != "" {
+				referencedMembers, err := referencedTeamMembers(generalConfiguration, teamDefinition.Team, teamDefinition.Task, path)
+				if err != nil {
+					errs = append(errs, err)
+				}
+				members = append(members, referencedMembers...)
+			} else if teamDefinition.Task != "" {
+				errs = append(errs, fmt.Errorf("%s.task: %q is set without a team", path, teamDefinition.Task))
+			}
+
+			if len(teamDefinition.Tags) > 0 {
+				var tagged []string
+				for _, tag := range teamDefinition.Tags {
+					if _, ok := generalConfiguration.Tags[tag]; !ok {
+						errs = append(errs, fmt.Errorf("%s.tags: unknown tag %q", path, tag))
+						continue
+					}
+					tagged = append(tagged, generalConfiguration.Tags[tag]...)
+				}
+
+				var filtered []string
+				for _, member := range members {
+					if slices.Contains(tagged, member) {
+						filtered = append(filtered, member)
+					}
+				}
+				members = filtered
+			}
+
+			teamDefinition.Members = uniqueMembers(members)
+			supportDefinition.Teams[teamName] = teamDefinition
+		}
+	}
+
+	return errs
+}
+
+func referencedTeamMembers(generalConfiguration *models.GeneralDefinition, team string, task string, path string) ([]string, error) {
+	taskMap, ok := generalConfiguration.Teams[team]
+	if !ok {
+		return nil, fmt.Errorf("%s.team: unknown team %q", path, team)
+	}
+
+	if task != "" {
+		if _, ok := taskMap[task]; !ok {
+			return nil, fmt.Errorf("%s.task: unknown task %q in team %q", path, task, team)
+		}
+		return taskMap[task].Members, nil
+	}
+
+	var members []string
+	for _, taskName := range sortedKeys(taskMap) {
+		members = append(members, taskMap[taskName].Members...)
+	}
+
+	return members, nil
+}
+
+func uniqueMembers(members []string) []string {
+	seen := make(map[string]struct{}, len(members))
+	unique := []string{}
+	for _, member := range members {
+		if _, ok := seen[member]; ok {
+			continue
+		}
+		seen[member] = struct{}{}
+		unique = append(unique, member)
+	}
+
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
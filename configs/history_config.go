@@ -0,0 +1,86 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"log"
+	"time"
+)
+
+type HistoryFilter struct {
+	Rotation models.RotationKey
+	Type     string
+	From     time.Time
+	To       time.Time
+}
+
+func RecordEvent(event models.HistoryEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	err := backend.AppendHistory(event)
+	if err != nil {
+		log.Println("Error writing history event:", err)
+	}
+}
+
+// GetHistory returns the recorded events matching the filter, oldest first. Empty filter fields
+// match every value.
+func GetHistory(filter HistoryFilter) []models.HistoryEvent {
+	history := []models.HistoryEvent{}
+
+	recorded, err := backend.ReadHistory()
+	if err != nil {
+		log.Println("Error reading history:", err)
+	}
+
+	for _, event := range recorded {
+		if !matchesRotation(filter.Rotation, event.Rotation) {
+			continue
+		}
+		if filter.Type != "" && filter.Type != event.Type {
+			continue
+		}
+		if !filter.From.IsZero() && event.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !event.Timestamp.Before(filter.To) {
+			continue
+		}
+
+		history = append(history, event)
+	}
+
+	return history
+}
+
+// GetSelectionHistory returns the explanation of every recorded selection and replacement.
+func GetSelectionHistory(rotation models.RotationKey) []models.SelectionExplanation {
+	history := []models.SelectionExplanation{}
+	for _, event := range GetHistory(HistoryFilter{Rotation: rotation}) {
+		if event.Explanation != nil {
+			history = append(history, *event.Explanation)
+		}
+	}
+
+	return history
+}
+
+func GetLastSelection(rotation models.RotationKey) (models.SelectionExplanation, bool) {
+	history := GetSelectionHistory(rotation)
+	if len(history) == 0 {
+		return models.SelectionExplanation{}, false
+	}
+
+	return history[len(history)-1], true
+}
+
+// matchesRotation reports whether an event of the rotation matches the filter. Events of a whole
+// group (skips and pauses) have no team, they match the filter of every team of the group.
+func matchesRotation(filter models.RotationKey, rotation models.RotationKey) bool {
+	groupEvent := rotation.Type == "groups" && rotation.Task == ""
+
+	return (filter.Type == "" || filter.Type == rotation.Type) &&
+		(filter.Name == "" || filter.Name == rotation.Name) &&
+		(filter.Task == "" || filter.Task == rotation.Task || groupEvent)
+}
@@ -0,0 +1,75 @@
+package configs
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches ${VAR} and ${VAR:-default}. The bare $VAR form is left alone, messages
+// may well mention prices.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?}`)
+
+// interpolateGeneralDefinition replaces the ${VAR} references in the configuration values with the
+// environment variables, so the same file works across environments. It returns one error per
+// variable that is not set and has no default.
+func interpolateGeneralDefinition(generalConfiguration *models.GeneralDefinition) []error {
+	var errs []error
+	interpolate := func(path string, value string) string {
+		return variablePattern.ReplaceAllStringFunc(value, func(reference string) string {
+			match := variablePattern.FindStringSubmatch(reference)
+			if variable, ok := os.LookupEnv(match[1]); ok {
+				return variable
+			}
+			if strings.Contains(reference, ":-") {
+				return match[2]
+			}
+
+			errs = append(errs, fmt.Errorf("%s: environment variable %s is not set", path, match[1]))
+			return reference
+		})
+	}
+
+	generalConfiguration.DefaultCron = interpolate("defaultCron", generalConfiguration.DefaultCron)
+	generalConfiguration.Onboarding.WelcomeMessage = interpolate("onboarding.welcomeMessage", generalConfiguration.Onboarding.WelcomeMessage)
+	generalConfiguration.Onboarding.FarewellMessage = interpolate("onboarding.farewellMessage", generalConfiguration.Onboarding.FarewellMessage)
+
+	for _, messageName := range sortedKeys(generalConfiguration.Messages) {
+		generalConfiguration.Messages[messageName] = interpolate("messages."+messageName, generalConfiguration.Messages[messageName])
+	}
+
+	for _, teamName := range sortedKeys(generalConfiguration.Teams) {
+		for _, taskName := range sortedKeys(generalConfiguration.Teams[teamName]) {
+			task := generalConfiguration.Teams[teamName][taskName]
+			path := fmt.Sprintf("teams.%s.%s", teamName, taskName)
+
+			task.Cron = interpolate(path+".cron", task.Cron)
+			task.Channel = interpolate(path+".channel", task.Channel)
+			task.Message = interpolate(path+".message", task.Message)
+			generalConfiguration.Teams[teamName][taskName] = task
+		}
+	}
+
+	for _, groupName := range sortedKeys(generalConfiguration.Groups) {
+		supportDefinition := generalConfiguration.Groups[groupName]
+		path := "groups." + groupName
+
+		supportDefinition.Cron = interpolate(path+".cron", supportDefinition.Cron)
+		supportDefinition.Channel = interpolate(path+".channel", supportDefinition.Channel)
+		supportDefinition.Message = interpolate(path+".message", supportDefinition.Message)
+		generalConfiguration.Groups[groupName] = supportDefinition
+	}
+
+	for _, memberName := range sortedKeys(generalConfiguration.Members) {
+		member := generalConfiguration.Members[memberName]
+		path := "members." + memberName
+
+		member.SlackID = interpolate(path+".slackId", member.SlackID)
+		member.Email = interpolate(path+".email", member.Email)
+		generalConfiguration.Members[memberName] = member
+	}
+
+	return errs
+}
@@ -0,0 +1,98 @@
+package configs
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"strings"
+)
+
+// GetMember returns the registry entry of a member, members missing from the registry are
+// treated as active members whose key is their Slack username.
+func GetMember(member string) (models.Member, bool) {
+	registeredMember, ok := GetGeneralConfiguration().Members[member]
+	return registeredMember, ok
+}
+
+// GetMemberSlackID returns the Slack user ID of a member, or the member key itself when the
+// member is not in the registry.
+func GetMemberSlackID(member string) string {
+	if registeredMember, ok := GetMember(member); ok && registeredMember.SlackID != "" {
+		return registeredMember.SlackID
+	}
+
+	return member
+}
+
+// GetMemberBySlackID returns the registry key of the member with the given Slack user ID.
+func GetMemberBySlackID(slackID string) (string, bool) {
+	if slackID == "" {
+		return "", false
+	}
+
+	for key, registeredMember := range GetGeneralConfiguration().Members {
+		if registeredMember.SlackID == slackID {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// GetCallingMember returns the member key of the Slack user calling a command: the registry key
+// when the Slack user ID is registered, otherwise the Slack username.
+func GetCallingMember(slackID string, userName string) string {
+	if member, ok := GetMemberBySlackID(slackID); ok {
+		return member
+	}
+
+	return userName
+}
+
+func Mention(member string) string {
+	return "<@" + GetMemberSlackID(member) + ">"
+}
+
+// Mentions renders members as "<@A>, <@B>, and <@C>".
+func Mentions(members []string) string {
+	mentions := make([]string, 0, len(members))
+	for _, member := range members {
+		mentions = append(mentions, Mention(member))
+	}
+
+	if len(mentions) <= 1 {
+		return strings.Join(mentions, "")
+	}
+
+	return strings.Join(mentions[:len(mentions)-1], ", ") + ", and " + mentions[len(mentions)-1]
+}
+
+// unregisteredMembers returns the path of every task or group member missing from the members
+// registry. Nothing is reported while the registry is empty.
+func unregisteredMembers(generalConfiguration models.GeneralDefinition) []string {
+	if len(generalConfiguration.Members) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for _, teamName := range sortedKeys(generalConfiguration.Teams) {
+		for _, taskName := range sortedKeys(generalConfiguration.Teams[teamName]) {
+			for _, member := range generalConfiguration.Teams[teamName][taskName].Members {
+				if _, ok := generalConfiguration.Members[member]; !ok {
+					paths = append(paths, fmt.Sprintf("teams.%s.%s.members: %s", teamName, taskName, member))
+				}
+			}
+		}
+	}
+
+	for _, groupName := range sortedKeys(generalConfiguration.Groups) {
+		for _, teamName := range sortedKeys(generalConfiguration.Groups[groupName].Teams) {
+			for _, member := range generalConfiguration.Groups[groupName].Teams[teamName].Members {
+				if _, ok := generalConfiguration.Members[member]; !ok {
+					paths = append(paths, fmt.Sprintf("groups.%s.teams.%s.members: %s", groupName, teamName, member))
+				}
+			}
+		}
+	}
+
+	return paths
+}
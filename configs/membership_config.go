@@ -0,0 +1,169 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/utils"
+	"log"
+	"slices"
+)
+
+const (
+	JoinPolicyImmediate = "immediate"
+	JoinPolicyNextCycle = "nextCycle"
+)
+
+type membershipMessage struct {
+	message string
+	member  string
+	channel string
+}
+
+// ApplyMembershipChanges compares the members of every task and group team with the roster
+// stored on the previous load. Members who joined are onboarded according to the join policy,
+// members who left are pruned from the stored selections.
+func ApplyMembershipChanges() {
+	generalConfiguration := GetGeneralConfiguration()
+
+	var messages []membershipMessage
+	err := State().UpdateSelections(func(teamCurrentSelection *models.TeamCurrentSelection, groupCurrentSelection *models.GroupCurrentSelection) error {
+		messages = nil
+
+		for _, teamName := range sortedKeys(generalConfiguration.Teams) {
+			for _, taskName := range sortedKeys(generalConfiguration.Teams[teamName]) {
+				task := generalConfiguration.Teams[teamName][taskName]
+				messages = append(messages, applyTaskMembershipChanges(teamCurrentSelection, generalConfiguration.Onboarding, teamName, taskName, task)...)
+			}
+		}
+
+		for _, groupName := range sortedKeys(generalConfiguration.Groups) {
+			supportDefinition := generalConfiguration.Groups[groupName]
+			for _, teamName := range sortedKeys(supportDefinition.Teams) {
+				messages = append(messages, applyGroupMembershipChanges(groupCurrentSelection, generalConfiguration.Onboarding, groupName, teamName, supportDefinition)...)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Println("Error applying membership changes:", err)
+		return
+	}
+
+	for _, message := range messages {
+		SendMessageToSlack(message.message, GetMemberSlackID(message.member), message.channel, "")
+	}
+}
+
+// GetPendingMembers returns the members who joined a rotation, or skipped the rest of its cycle, and
+// wait for its next cycle.
+func GetPendingMembers(rotation models.RotationKey) []string {
+	if rotation.Type == "teams" {
+		return State().TaskSelection(rotation.Name, rotation.Task).Pending
+	}
+
+	if rotation.Type == "groups" {
+		return State().SupportSelection(rotation.Name).Pending[rotation.Task]
+	}
+
+	return nil
+}
+
+func applyTaskMembershipChanges(teamCurrentSelection *models.TeamCurrentSelection, onboarding models.OnboardingPolicy, teamName string, taskName string, task models.Task) []membershipMessage {
+	var messages []membershipMessage
+	if _, ok := teamCurrentSelection.Teams[teamName]; !ok {
+		teamCurrentSelection.Teams[teamName] = make(map[string]models.TaskSelection)
+	}
+
+	taskSelection, ok := teamCurrentSelection.Teams[teamName][taskName]
+	if ok && taskSelection.Roster != nil {
+		joined := utils.Difference(task.Members, taskSelection.Roster)
+		left := utils.Difference(taskSelection.Roster, task.Members)
+
+		for _, member := range joined {
+			log.Printf("[%s] :: %s member %s joined", teamName, taskName, member)
+			if onboarding.JoinPolicy == JoinPolicyNextCycle {
+				taskSelection.Pending = append(taskSelection.Pending, member)
+			}
+			messages = append(messages, membershipMessage{onboarding.WelcomeMessage, member, task.Channel})
+		}
+
+		for _, member := range left {
+			log.Printf("[%s] :: %s member %s left", teamName, taskName, member)
+			taskSelection.Members = removeMember(taskSelection.Members, member)
+			taskSelection.Pending = removeMember(taskSelection.Pending, member)
+			messages = append(messages, membershipMessage{onboarding.FarewellMessage, member, task.Channel})
+		}
+	}
+
+	if taskSelection.Members == nil {
+		taskSelection.Members = []string{}
+	}
+	taskSelection.Roster = append([]string{}, task.Members...)
+	teamCurrentSelection.Teams[teamName][taskName] = taskSelection
+
+	return withMessage(messages)
+}
+
+func applyGroupMembershipChanges(groupCurrentSelection *models.GroupCurrentSelection, onboarding models.OnboardingPolicy, groupName string, teamName string, supportDefinition models.SupportDefinition) []membershipMessage {
+	var messages []membershipMessage
+	storedSupportDefinition, ok := groupCurrentSelection.Groups[groupName]
+	if !ok {
+		storedSupportDefinition = models.StoredSupportDefinition{Teams: make(map[string][]string)}
+	}
+	if storedSupportDefinition.Teams == nil {
+		storedSupportDefinition.Teams = make(map[string][]string)
+	}
+	if storedSupportDefinition.Rosters == nil {
+		storedSupportDefinition.Rosters = make(map[string][]string)
+	}
+	if storedSupportDefinition.Pending == nil {
+		storedSupportDefinition.Pending = make(map[string][]string)
+	}
+
+	members := supportDefinition.Teams[teamName].Members
+	roster, ok := storedSupportDefinition.Rosters[teamName]
+	if ok {
+		joined := utils.Difference(members, roster)
+		left := utils.Difference(roster, members)
+
+		for _, member := range joined {
+			log.Printf("[%s] :: %s member %s joined", groupName, teamName, member)
+			if onboarding.JoinPolicy == JoinPolicyNextCycle {
+				storedSupportDefinition.Pending[teamName] = append(storedSupportDefinition.Pending[teamName], member)
+			}
+			messages = append(messages, membershipMessage{onboarding.WelcomeMessage, member, supportDefinition.Channel})
+		}
+
+		for _, member := range left {
+			log.Printf("[%s] :: %s member %s left", groupName, teamName, member)
+			storedSupportDefinition.Teams[teamName] = removeMember(storedSupportDefinition.Teams[teamName], member)
+			storedSupportDefinition.Pending[teamName] = removeMember(storedSupportDefinition.Pending[teamName], member)
+			messages = append(messages, membershipMessage{onboarding.FarewellMessage, member, supportDefinition.Channel})
+		}
+	}
+
+	if len(storedSupportDefinition.Pending[teamName]) == 0 {
+		delete(storedSupportDefinition.Pending, teamName)
+	}
+	storedSupportDefinition.Rosters[teamName] = append([]string{}, members...)
+	groupCurrentSelection.Groups[groupName] = storedSupportDefinition
+
+	return withMessage(messages)
+}
+
+// withMessage drops the announcements that have no message configured.
+func withMessage(messages []membershipMessage) []membershipMessage {
+	return slices.DeleteFunc(messages, func(message membershipMessage) bool {
+		return message.message == ""
+	})
+}
+
+func removeMember(members []string, member string) []string {
+	if members == nil {
+		return nil
+	}
+
+	return slices.DeleteFunc(append([]string{}, members...), func(element string) bool {
+		return element == member
+	})
+}
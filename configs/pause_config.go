@@ -0,0 +1,43 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"slices"
+	"time"
+)
+
+// GetPause returns the pause of a rotation at the given time, if any. A pause without an end
+// lasts until it is removed.
+func GetPause(rotation models.RotationKey, at time.Time) (models.Pause, bool) {
+	for _, pause := range GetPauses() {
+		if pause.Rotation == rotation && (pause.Until.IsZero() || at.Before(pause.Until)) {
+			return pause, true
+		}
+	}
+
+	return models.Pause{}, false
+}
+
+func GetPauses() []models.Pause {
+	return State().Pauses().Pauses
+}
+
+// AddPause pauses a rotation, replacing its current pause if any.
+func AddPause(pause models.Pause) error {
+	return State().UpdatePauses(func(pauses *models.PauseStorage) error {
+		pauses.Pauses = slices.DeleteFunc(pauses.Pauses, func(existing models.Pause) bool {
+			return existing.Rotation == pause.Rotation
+		})
+		pauses.Pauses = append(pauses.Pauses, pause)
+		return nil
+	})
+}
+
+func RemovePause(rotation models.RotationKey) error {
+	return State().UpdatePauses(func(pauses *models.PauseStorage) error {
+		pauses.Pauses = slices.DeleteFunc(pauses.Pauses, func(pause models.Pause) bool {
+			return pause.Rotation == rotation
+		})
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package configs
+
+import (
+	"fmt"
+	"github.com/slack-go/slack"
+	"io.mt-borring.bot/models"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RoleMember = "member"
+	RoleLead   = "lead"
+	RoleAdmin  = "admin"
+)
+
+// usergroupCacheTTL bounds how long the members of the Slack user groups are trusted.
+const usergroupCacheTTL = 5 * time.Minute
+
+var usergroupCacheMutex sync.Mutex
+var usergroupCache map[string][]string
+var usergroupCacheTime time.Time
+
+// IsAdmin tells whether the Slack user holds the admin role, from the members registry or the
+// admin user group.
+func IsAdmin(slackID string) bool {
+	if member, ok := GetMemberBySlackID(slackID); ok && slices.Contains(GetGeneralConfiguration().Members[member].Roles, RoleAdmin) {
+		return true
+	}
+
+	return isUsergroupMember(GetGeneralConfiguration().Permissions.AdminUsergroup, slackID)
+}
+
+// IsLead tells whether the Slack user leads the team or group of a rotation, or the team of a group
+// rotation. Leads are granted "lead:<team or group>" in the members registry or belong to the lead
+// user group of the team or group.
+func IsLead(slackID string, rotation models.RotationKey) bool {
+	led := []string{rotation.Name}
+	if rotation.Type == "groups" && rotation.Task != "" {
+		led = append(led, rotation.Task)
+	}
+
+	generalConfiguration := GetGeneralConfiguration()
+	member, registered := GetMemberBySlackID(slackID)
+	for _, name := range led {
+		if registered && slices.Contains(generalConfiguration.Members[member].Roles, RoleLead+":"+name) {
+			return true
+		}
+		if isUsergroupMember(generalConfiguration.Permissions.LeadUsergroups[name], slackID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckRotationPermission returns why the Slack user may not change a rotation on behalf of member.
+// Members may only act on themselves, leads on the rotations of their team or group and admins on
+// every rotation. The member is the Slack username when the user is not in the members registry.
+func CheckRotationPermission(slackID string, userName string, rotation models.RotationKey, member string) error {
+	if IsAdmin(slackID) || IsLead(slackID, rotation) {
+		return nil
+	}
+
+	if member != "" && member == GetCallingMember(slackID, userName) {
+		return nil
+	}
+
+	if member == "" {
+		return fmt.Errorf("only the leads of %s and admins can do that", rotation.Name)
+	}
+	return fmt.Errorf("you can only do that for yourself, ask a lead of %s or an admin to do it for %s", rotation.Name, member)
+}
+
+// isUsergroupMember looks the Slack user up in a user group, by name or handle.
+func isUsergroupMember(usergroup string, slackID string) bool {
+	if usergroup == "" {
+		return false
+	}
+
+	return slices.Contains(usergroupMembers()[strings.TrimPrefix(usergroup, "@")], slackID)
+}
+
+// usergroupMembers returns the members of every Slack user group by name and handle, fetched at
+// most once every usergroupCacheTTL.
+func usergroupMembers() map[string][]string {
+	usergroupCacheMutex.Lock()
+	defer usergroupCacheMutex.Unlock()
+
+	if usergroupCache != nil && time.Since(usergroupCacheTime) < usergroupCacheTTL {
+		return usergroupCache
+	}
+
+	userGroups, err := slackApi.GetUserGroups(slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		log.Println("Error listing the Slack user groups:", err)
+		return usergroupCache
+	}
+
+	usergroupCache = make(map[string][]string, 2*len(userGroups))
+	for _, userGroup := range userGroups {
+		usergroupCache[userGroup.Name] = userGroup.Users
+		usergroupCache[userGroup.Handle] = userGroup.Users
+	}
+	usergroupCacheTime = time.Now()
+
+	return usergroupCache
+}
@@ -0,0 +1,53 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"strings"
+)
+
+// GetMemberPreferences merges the preferences declared in the configuration with
+// the ones the member set through the /prefs command.
+func GetMemberPreferences(member string) models.MemberPreferences {
+	configured := GetGeneralConfiguration().Preferences[member]
+	stored := State().MemberPreferences(member)
+
+	return models.MemberPreferences{
+		ExcludedDays:    mergePreferenceValues(configured.ExcludedDays, stored.ExcludedDays),
+		ExcludedShifts:  mergePreferenceValues(configured.ExcludedShifts, stored.ExcludedShifts),
+		PreferredDays:   mergePreferenceValues(configured.PreferredDays, stored.PreferredDays),
+		PreferredShifts: mergePreferenceValues(configured.PreferredShifts, stored.PreferredShifts),
+	}
+}
+
+func GetStoredMemberPreferences(member string) models.MemberPreferences {
+	return State().MemberPreferences(member)
+}
+
+func SetStoredMemberPreferences(member string, preferences models.MemberPreferences) error {
+	return State().UpdateMemberPreferences(func(memberPreferences *models.MemberPreferencesStorage) error {
+		memberPreferences.Members[member] = preferences
+		return nil
+	})
+}
+
+func ClearStoredMemberPreferences(member string) error {
+	return State().UpdateMemberPreferences(func(memberPreferences *models.MemberPreferencesStorage) error {
+		delete(memberPreferences.Members, member)
+		return nil
+	})
+}
+
+func mergePreferenceValues(configured []string, stored []string) []string {
+	seen := make(map[string]struct{})
+	var merged []string
+	for _, value := range append(append([]string{}, configured...), stored...) {
+		value = strings.ToLower(value)
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		merged = append(merged, value)
+	}
+
+	return merged
+}
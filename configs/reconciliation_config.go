@@ -0,0 +1,200 @@
+package configs
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"log"
+	"slices"
+)
+
+// ReconcileState brings the stored state in line with the general definition: selections of teams,
+// tasks and groups that are no longer configured are dropped, departed members are removed from
+// the current cycles, cycles holding a member more than once are trimmed, and pauses of unknown
+// rotations are lifted. It returns a summary of the changes, which are only reported on a dry run.
+//
+// The stored rosters of configured rotations are left alone, membership changes compare them with
+// the configuration to welcome and say farewell to members.
+func ReconcileState(dryRun bool) ([]string, error) {
+	generalConfiguration := GetGeneralConfiguration()
+
+	if dryRun {
+		teamCurrentSelection := State().TeamSelection()
+		groupCurrentSelection := State().GroupSelection()
+		pauses := State().Pauses()
+
+		changes := reconcileSelections(generalConfiguration, &teamCurrentSelection, &groupCurrentSelection)
+		return append(changes, reconcilePauses(generalConfiguration, &pauses)...), nil
+	}
+
+	var changes []string
+	err := State().UpdateSelections(func(teamCurrentSelection *models.TeamCurrentSelection, groupCurrentSelection *models.GroupCurrentSelection) error {
+		changes = reconcileSelections(generalConfiguration, teamCurrentSelection, groupCurrentSelection)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pauseChanges []string
+	err = State().UpdatePauses(func(pauses *models.PauseStorage) error {
+		pauseChanges = reconcilePauses(generalConfiguration, pauses)
+		return nil
+	})
+	if err != nil {
+		return changes, err
+	}
+
+	return append(changes, pauseChanges...), nil
+}
+
+// reconcileStateOnLoad reconciles the state loaded from the backend, only reporting the changes
+// when dryRun is set.
+func reconcileStateOnLoad(dryRun bool) {
+	changes, err := ReconcileState(dryRun)
+	if err != nil {
+		log.Println("Error reconciling the stored state:", err)
+		return
+	}
+
+	prefix := "Reconciled state:"
+	if dryRun {
+		prefix = "Reconciliation (dry run) would change:"
+	}
+	for _, change := range changes {
+		log.Println(prefix, change)
+	}
+	log.Printf("State reconciliation found %d change(s)", len(changes))
+}
+
+func reconcileSelections(generalConfiguration models.GeneralDefinition, teamCurrentSelection *models.TeamCurrentSelection, groupCurrentSelection *models.GroupCurrentSelection) []string {
+	var changes []string
+
+	for _, teamName := range sortedKeys(teamCurrentSelection.Teams) {
+		taskMap, ok := generalConfiguration.Teams[teamName]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("teams.%s: dropped, the team is no longer configured", teamName))
+			delete(teamCurrentSelection.Teams, teamName)
+			continue
+		}
+
+		for _, taskName := range sortedKeys(teamCurrentSelection.Teams[teamName]) {
+			path := fmt.Sprintf("teams.%s.%s", teamName, taskName)
+			task, ok := taskMap[taskName]
+			if !ok {
+				changes = append(changes, path+": dropped, the task is no longer configured")
+				delete(teamCurrentSelection.Teams[teamName], taskName)
+				continue
+			}
+
+			taskSelection := teamCurrentSelection.Teams[teamName][taskName]
+			var taskChanges []string
+			taskSelection.Members, taskChanges = reconcileCycle(path, taskSelection.Members, task.Members)
+			changes = append(changes, taskChanges...)
+			taskSelection.Pending, taskChanges = reconcileCycle(path+".pending", taskSelection.Pending, task.Members)
+			changes = append(changes, taskChanges...)
+			teamCurrentSelection.Teams[teamName][taskName] = taskSelection
+		}
+	}
+
+	for _, groupName := range sortedKeys(groupCurrentSelection.Groups) {
+		supportDefinition, ok := generalConfiguration.Groups[groupName]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("groups.%s: dropped, the group is no longer configured", groupName))
+			delete(groupCurrentSelection.Groups, groupName)
+			continue
+		}
+
+		storedSupportDefinition := groupCurrentSelection.Groups[groupName]
+		for _, teamName := range sortedKeys(storedSupportDefinition.Teams) {
+			path := fmt.Sprintf("groups.%s.%s", groupName, teamName)
+			teamDefinition, ok := supportDefinition.Teams[teamName]
+			if !ok {
+				changes = append(changes, path+": dropped, the team is no longer part of the group")
+				delete(storedSupportDefinition.Teams, teamName)
+				delete(storedSupportDefinition.Rosters, teamName)
+				delete(storedSupportDefinition.Pending, teamName)
+				continue
+			}
+
+			var teamChanges []string
+			storedSupportDefinition.Teams[teamName], teamChanges = reconcileCycle(path, storedSupportDefinition.Teams[teamName], teamDefinition.Members)
+			changes = append(changes, teamChanges...)
+		}
+
+		for _, teamName := range sortedKeys(storedSupportDefinition.Pending) {
+			path := fmt.Sprintf("groups.%s.%s.pending", groupName, teamName)
+			var teamChanges []string
+			storedSupportDefinition.Pending[teamName], teamChanges = reconcileCycle(path, storedSupportDefinition.Pending[teamName], supportDefinition.Teams[teamName].Members)
+			changes = append(changes, teamChanges...)
+			if len(storedSupportDefinition.Pending[teamName]) == 0 {
+				delete(storedSupportDefinition.Pending, teamName)
+			}
+		}
+
+		if teams := len(supportDefinition.Teams); teams > 0 && storedSupportDefinition.RotationOffset >= teams {
+			changes = append(changes, fmt.Sprintf("groups.%s.rotationOffset: wrapped from %d to %d", groupName, storedSupportDefinition.RotationOffset, storedSupportDefinition.RotationOffset%teams))
+			storedSupportDefinition.RotationOffset %= teams
+		}
+
+		groupCurrentSelection.Groups[groupName] = storedSupportDefinition
+	}
+
+	return changes
+}
+
+// reconcileCycle keeps the members of a cycle that are still configured, each one once.
+func reconcileCycle(path string, cycle []string, members []string) ([]string, []string) {
+	if cycle == nil {
+		return nil, nil
+	}
+
+	var changes []string
+	reconciled := []string{}
+	for _, member := range cycle {
+		if !slices.Contains(members, member) {
+			changes = append(changes, fmt.Sprintf("%s: removed departed member %s", path, member))
+			continue
+		}
+
+		if slices.Contains(reconciled, member) {
+			changes = append(changes, fmt.Sprintf("%s: trimmed %s, listed more than once in the cycle", path, member))
+			continue
+		}
+
+		reconciled = append(reconciled, member)
+	}
+
+	return reconciled, changes
+}
+
+func reconcilePauses(generalConfiguration models.GeneralDefinition, pauses *models.PauseStorage) []string {
+	var changes []string
+
+	pauses.Pauses = slices.DeleteFunc(pauses.Pauses, func(pause models.Pause) bool {
+		if isConfiguredRotation(generalConfiguration, pause.Rotation) {
+			return false
+		}
+
+		changes = append(changes, fmt.Sprintf("pauses: lifted the pause of %s, the rotation is no longer configured", pause.Rotation))
+		return true
+	})
+
+	return changes
+}
+
+func isConfiguredRotation(generalConfiguration models.GeneralDefinition, rotation models.RotationKey) bool {
+	switch rotation.Type {
+	case "teams":
+		_, ok := generalConfiguration.Teams[rotation.Name][rotation.Task]
+		return ok
+	case "groups":
+		supportDefinition, ok := generalConfiguration.Groups[rotation.Name]
+		if !ok || rotation.Task == "" {
+			return ok
+		}
+		_, ok = supportDefinition.Teams[rotation.Task]
+		return ok
+	default:
+		return false
+	}
+}
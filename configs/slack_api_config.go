@@ -10,8 +10,13 @@ import (
 
 var slackApi *slack.Client
 
+// InitSlackApi creates the Slack client, against SLACK_API_URL when set instead of slack.com.
 func InitSlackApi() {
 	slackToken := os.Getenv("SLACK_TOKEN")
+	if apiURL := os.Getenv("SLACK_API_URL"); apiURL != "" {
+		slackApi = slack.New(slackToken, slack.OptionAPIURL(apiURL))
+		return
+	}
 	slackApi = slack.New(slackToken)
 }
 
@@ -19,24 +24,27 @@ func GetSlackApi() *slack.Client {
 	return slackApi
 }
 
-func SendMessageToSlack(slackMessage string, member string, channel string, taskName string) {
+// SendMessageToSlack posts the message and returns its timestamp, empty when nothing was posted.
+func SendMessageToSlack(slackMessage string, member string, channel string, taskName string) string {
 	if channel == "" {
 		log.Println("Channel is empty, skipping sending message to Slack")
-		return
+		return ""
 	}
 
 	messageToPublish := GetMessageToPublish(slackMessage, taskName)
 	messageToPublish = strings.Replace(messageToPublish, "{{name}}", member, -1)
 
-	_, _, err := slackApi.PostMessage(
+	_, timestamp, err := slackApi.PostMessage(
 		channel,
 		slack.MsgOptionText(messageToPublish, false),
 		slack.MsgOptionAsUser(true),
 	)
 	if err != nil {
 		log.Printf("Error sending message to Slack: %s\n", err)
-		return
+		return ""
 	}
+
+	return timestamp
 }
 
 func UpdateSlackGroup(users []string, groupName string) {
@@ -67,29 +75,33 @@ func UpdateSlackGroup(users []string, groupName string) {
 	userIds, _ := getUserIDsFromNames(users)
 	_, err = slackApi.UpdateUserGroupMembers(groupId, strings.Join(userIds, ","))
 	if err != nil {
-		log.Printf("failed to remove user from group: %w", err)
+		log.Printf("failed to remove user from group: %v", err)
 		return
 	}
 }
 
 func getUserIDsFromNames(userNames []string) ([]string, error) {
 	var userIDs []string
+	var users []slack.User
 
-	// List all users to find their IDs by their names
-	users, err := slackApi.GetUsers()
-	if err != nil {
-		return nil, err
-	}
+	// Convert user names to user IDs, preferring the ones in the members registry
+	for _, userName := range userNames {
+		registeredMember, _ := GetMember(userName)
+		if registeredMember.SlackID != "" {
+			userIDs = append(userIDs, registeredMember.SlackID)
+			continue
+		}
 
-	// Map user names to user IDs
-	userNameToID := make(map[string]string)
-	for _, user := range users {
-		userNameToID[user.Name] = user.ID
-	}
+		// List all users to find their IDs by their email or names
+		if users == nil {
+			var err error
+			users, err = slackApi.GetUsers()
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	// Convert user names to user IDs
-	for _, userName := range userNames {
-		userID, ok := userNameToID[userName]
+		userID, ok := findUserID(users, userName, registeredMember.Email)
 		if !ok {
 			return nil, fmt.Errorf("user %s not found", userName)
 		}
@@ -98,3 +110,17 @@ func getUserIDsFromNames(userNames []string) ([]string, error) {
 
 	return userIDs, nil
 }
+
+func findUserID(users []slack.User, userName string, email string) (string, bool) {
+	for _, user := range users {
+		if email != "" && strings.EqualFold(email, user.Profile.Email) {
+			return user.ID, true
+		}
+
+		if email == "" && userName == user.Name {
+			return user.ID, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,130 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"sort"
+)
+
+const (
+	AllocationEqual        = "equal"
+	AllocationProportional = "proportional"
+	AllocationRotating     = "rotating"
+)
+
+// AllocateSquad returns how many members each team of the group contributes to the next squad.
+// Without a squadSize every team contributes its own amount, or amountFromEachTeam when omitted.
+// The rotation offset is the first team contributing to a rotating squad. No team contributes more
+// members than it has, what a team cannot take is handed out to the next teams in turn.
+func AllocateSquad(supportDefinition models.SupportDefinition, rotationOffset int) map[string]int {
+	teamNames := sortedKeys(supportDefinition.Teams)
+
+	amounts := make(map[string]int, len(teamNames))
+	if supportDefinition.SquadSize == 0 || len(teamNames) == 0 {
+		for _, teamName := range teamNames {
+			amounts[teamName] = supportDefinition.Teams[teamName].Amount
+			if amounts[teamName] == 0 {
+				amounts[teamName] = supportDefinition.AmountFromEachTeam
+			}
+		}
+		return amounts
+	}
+
+	order := teamNames
+	switch supportDefinition.Allocation {
+	case AllocationProportional:
+		order = allocateProportionally(amounts, teamNames, supportDefinition)
+	case AllocationRotating:
+		offset := rotationOffset % len(teamNames)
+		order = append(append([]string{}, teamNames[offset:]...), teamNames[:offset]...)
+		for i := 0; i < supportDefinition.SquadSize; i++ {
+			amounts[order[i%len(order)]]++
+		}
+	default:
+		for i, teamName := range teamNames {
+			amounts[teamName] = supportDefinition.SquadSize / len(teamNames)
+			if i < supportDefinition.SquadSize%len(teamNames) {
+				amounts[teamName]++
+			}
+		}
+	}
+
+	capAllocation(amounts, order, supportDefinition)
+	return amounts
+}
+
+// capAllocation limits every team to its number of members and hands the overflow out one member at
+// a time to the teams that still have members left, in the given order.
+func capAllocation(amounts map[string]int, order []string, supportDefinition models.SupportDefinition) {
+	overflow := 0
+	for _, teamName := range order {
+		if members := len(supportDefinition.Teams[teamName].Members); amounts[teamName] > members {
+			overflow += amounts[teamName] - members
+			amounts[teamName] = members
+		}
+	}
+
+	for overflow > 0 {
+		handedOut := false
+		for _, teamName := range order {
+			if overflow > 0 && amounts[teamName] < len(supportDefinition.Teams[teamName].Members) {
+				amounts[teamName]++
+				overflow--
+				handedOut = true
+			}
+		}
+		if !handedOut {
+			return
+		}
+	}
+}
+
+// allocateProportionally splits the squad by team size using the largest remainder method, and
+// returns the teams by remainder.
+func allocateProportionally(amounts map[string]int, teamNames []string, supportDefinition models.SupportDefinition) []string {
+	totalMembers := 0
+	for _, teamName := range teamNames {
+		totalMembers += len(supportDefinition.Teams[teamName].Members)
+	}
+
+	if totalMembers == 0 {
+		return teamNames
+	}
+
+	remainders := make(map[string]int, len(teamNames))
+	allocated := 0
+	for _, teamName := range teamNames {
+		share := supportDefinition.SquadSize * len(supportDefinition.Teams[teamName].Members)
+		amounts[teamName] = share / totalMembers
+		remainders[teamName] = share % totalMembers
+		allocated += amounts[teamName]
+	}
+
+	byRemainder := append([]string{}, teamNames...)
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return remainders[byRemainder[i]] > remainders[byRemainder[j]]
+	})
+
+	for i := 0; allocated < supportDefinition.SquadSize; i++ {
+		amounts[byRemainder[i%len(byRemainder)]]++
+		allocated++
+	}
+
+	return byRemainder
+}
+
+// worstSquadAllocation returns the most members each team may have to contribute to a squad, over
+// every rotation offset.
+func worstSquadAllocation(supportDefinition models.SupportDefinition) map[string]int {
+	worst := AllocateSquad(supportDefinition, 0)
+	if supportDefinition.Allocation != AllocationRotating {
+		return worst
+	}
+
+	for offset := 1; offset < len(supportDefinition.Teams); offset++ {
+		for teamName, amount := range AllocateSquad(supportDefinition, offset) {
+			worst[teamName] = max(worst[teamName], amount)
+		}
+	}
+
+	return worst
+}
@@ -0,0 +1,40 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"maps"
+	"testing"
+)
+
+func TestAllocateSquadWithUnevenTeams(t *testing.T) {
+	teams := map[string]models.TeamDefinition{
+		"payments-iris": {Members: []string{"ana"}},
+		"payments-zeus": {Members: []string{"maria", "fabio", "pedro", "rita", "joana"}},
+		"payments-hera": {Members: []string{"andre", "francisco"}},
+	}
+
+	tests := []struct {
+		name           string
+		allocation     string
+		squadSize      int
+		rotationOffset int
+		want           map[string]int
+	}{
+		{name: "equal", allocation: AllocationEqual, squadSize: 6, want: map[string]int{"payments-hera": 2, "payments-iris": 1, "payments-zeus": 3}},
+		{name: "equal by default", squadSize: 8, want: map[string]int{"payments-hera": 2, "payments-iris": 1, "payments-zeus": 5}},
+		{name: "proportional", allocation: AllocationProportional, squadSize: 7, want: map[string]int{"payments-hera": 2, "payments-iris": 1, "payments-zeus": 4}},
+		{name: "rotating", allocation: AllocationRotating, squadSize: 6, want: map[string]int{"payments-hera": 2, "payments-iris": 1, "payments-zeus": 3}},
+		{name: "rotating from another team", allocation: AllocationRotating, squadSize: 4, rotationOffset: 1, want: map[string]int{"payments-hera": 1, "payments-iris": 1, "payments-zeus": 2}},
+		{name: "squad larger than the group", allocation: AllocationEqual, squadSize: 10, want: map[string]int{"payments-hera": 2, "payments-iris": 1, "payments-zeus": 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			supportDefinition := models.SupportDefinition{Teams: teams, SquadSize: test.squadSize, Allocation: test.allocation}
+			amounts := AllocateSquad(supportDefinition, test.rotationOffset)
+			if !maps.Equal(amounts, test.want) {
+				t.Errorf("allocated %v, want %v", amounts, test.want)
+			}
+		})
+	}
+}
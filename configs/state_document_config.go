@@ -0,0 +1,302 @@
+package configs
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/storage"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	ImportMerge   = "merge"
+	ImportReplace = "replace"
+)
+
+// ImportOptions selects how a state document is imported. Merge keeps the current entries the
+// document does not mention, replace drops them. A team limits the import to the selections,
+// pauses and history of that team.
+type ImportOptions struct {
+	Mode string
+	Team string
+}
+
+type ImportSummary struct {
+	Mode           string `json:"mode"`
+	Team           string `json:"team,omitempty"`
+	Tasks          int    `json:"tasks"`
+	Groups         int    `json:"groups"`
+	Preferences    int    `json:"preferences"`
+	Availability   int    `json:"availability"`
+	Pauses         int    `json:"pauses"`
+	HistoryEvents  int    `json:"historyEvents"`
+	SkippedHistory int    `json:"skippedHistory"`
+}
+
+// ExportState returns the whole state, or only the selections, pauses and history of a team.
+func ExportState(team string) (models.StateDocument, error) {
+	history, err := backend.ReadHistory()
+	if err != nil {
+		return models.StateDocument{}, err
+	}
+
+	document := models.StateDocument{
+		SchemaVersion:     storage.SchemaVersion(),
+		ExportedAt:        time.Now(),
+		TeamSelection:     State().TeamSelection(),
+		GroupSelection:    State().GroupSelection(),
+		History:           history,
+		MemberPreferences: State().AllMemberPreferences(),
+		Availability:      State().Availability(),
+		Pauses:            State().Pauses(),
+	}
+
+	if team != "" {
+		document = teamDocument(document, team)
+	}
+
+	return document, nil
+}
+
+// ImportState validates the document against the configuration and applies the selections, pauses,
+// preferences and availability in a single state update. Nothing is applied when the document is
+// invalid, documents of older schema versions have to be migrated by storage.DecodeStateDocument
+// first. History is append-only, so in both modes only the events that are not recorded yet are
+// appended and importing the same document again appends nothing.
+func ImportState(document models.StateDocument, options ImportOptions) (ImportSummary, []error) {
+	generalConfiguration := GetGeneralConfiguration()
+	if options.Mode == "" {
+		options.Mode = ImportMerge
+	}
+
+	errs := validateStateDocument(generalConfiguration, document, options)
+	if len(errs) > 0 {
+		return ImportSummary{}, errs
+	}
+
+	if options.Team != "" {
+		document = teamDocument(document, options.Team)
+	}
+
+	var summary ImportSummary
+	err := State().UpdateAll(func(current *models.StateDocument) error {
+		summary = ImportSummary{Mode: options.Mode, Team: options.Team}
+
+		if options.Mode == ImportReplace {
+			if options.Team != "" {
+				delete(current.TeamSelection.Teams, options.Team)
+			} else {
+				current.TeamSelection.Teams = make(map[string]map[string]models.TaskSelection)
+				current.GroupSelection.Groups = make(map[string]models.StoredSupportDefinition)
+			}
+		}
+
+		for teamName, tasks := range document.TeamSelection.Teams {
+			if _, ok := current.TeamSelection.Teams[teamName]; !ok {
+				current.TeamSelection.Teams[teamName] = make(map[string]models.TaskSelection)
+			}
+			for taskName, taskSelection := range tasks {
+				current.TeamSelection.Teams[teamName][taskName] = taskSelection.Clone()
+				summary.Tasks++
+			}
+		}
+
+		for groupName, storedSupportDefinition := range document.GroupSelection.Groups {
+			current.GroupSelection.Groups[groupName] = storedSupportDefinition.Clone()
+			summary.Groups++
+		}
+
+		current.Pauses.Pauses = slices.DeleteFunc(current.Pauses.Pauses, func(existing models.Pause) bool {
+			if options.Mode == ImportReplace && (options.Team == "" || isTeamRotation(existing.Rotation, options.Team)) {
+				return true
+			}
+			return slices.ContainsFunc(document.Pauses.Pauses, func(imported models.Pause) bool {
+				return imported.Rotation == existing.Rotation
+			})
+		})
+		current.Pauses.Pauses = append(current.Pauses.Pauses, document.Pauses.Pauses...)
+		summary.Pauses = len(document.Pauses.Pauses)
+
+		// Preferences and availability belong to members, not teams
+		if options.Team != "" {
+			return nil
+		}
+
+		if options.Mode == ImportReplace {
+			current.MemberPreferences.Members = make(map[string]models.MemberPreferences)
+			current.Availability.Members = make(map[string][]models.AwayPeriod)
+		}
+		for member, preferences := range document.MemberPreferences.Members {
+			current.MemberPreferences.Members[member] = preferences.Clone()
+			summary.Preferences++
+		}
+		for member, periods := range document.Availability.Members {
+			current.Availability.Members[member] = append([]models.AwayPeriod{}, periods...)
+			summary.Availability++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ImportSummary{}, []error{err}
+	}
+
+	recorded, err := backend.ReadHistory()
+	if err != nil {
+		return summary, []error{err}
+	}
+	for _, event := range document.History {
+		if slices.ContainsFunc(recorded, func(existing models.HistoryEvent) bool { return sameEvent(existing, event) }) {
+			summary.SkippedHistory++
+			continue
+		}
+
+		if err := backend.AppendHistory(event); err != nil {
+			return summary, []error{err}
+		}
+		summary.HistoryEvents++
+	}
+
+	log.Printf("Imported state (%s): %d tasks, %d groups, %d preferences, %d availability, %d pauses, %d history events",
+		summary.Mode, summary.Tasks, summary.Groups, summary.Preferences, summary.Availability, summary.Pauses, summary.HistoryEvents)
+
+	return summary, nil
+}
+
+func validateStateDocument(generalConfiguration models.GeneralDefinition, document models.StateDocument, options ImportOptions) []error {
+	var errs []error
+
+	if options.Mode != ImportMerge && options.Mode != ImportReplace {
+		errs = append(errs, fmt.Errorf("mode: unknown mode %q, expected %s or %s", options.Mode, ImportMerge, ImportReplace))
+	}
+
+	if options.Team != "" {
+		if _, ok := generalConfiguration.Teams[options.Team]; !ok {
+			errs = append(errs, fmt.Errorf("team: unknown team %q", options.Team))
+		}
+	}
+
+	if document.SchemaVersion != storage.SchemaVersion() {
+		errs = append(errs, fmt.Errorf("schemaVersion: %d is not the version %d of this build, decode the document with its migrations first", document.SchemaVersion, storage.SchemaVersion()))
+	}
+
+	for _, teamName := range sortedKeys(document.TeamSelection.Teams) {
+		if options.Team != "" && teamName != options.Team {
+			continue
+		}
+
+		for _, taskName := range sortedKeys(document.TeamSelection.Teams[teamName]) {
+			path := fmt.Sprintf("teamSelection.teams.%s.%s", teamName, taskName)
+			task, ok := generalConfiguration.Teams[teamName][taskName]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown team task", path))
+				continue
+			}
+
+			taskSelection := document.TeamSelection.Teams[teamName][taskName]
+			errs = append(errs, validateDocumentMembers(path+".members", taskSelection.Members, task.Members)...)
+			errs = append(errs, validateDocumentMembers(path+".pending", taskSelection.Pending, task.Members)...)
+		}
+	}
+
+	if options.Team == "" {
+		for _, groupName := range sortedKeys(document.GroupSelection.Groups) {
+			supportDefinition, ok := generalConfiguration.Groups[groupName]
+			if !ok {
+				errs = append(errs, fmt.Errorf("groupSelection.groups.%s: unknown group", groupName))
+				continue
+			}
+
+			storedSupportDefinition := document.GroupSelection.Groups[groupName]
+			for _, teamName := range sortedKeys(storedSupportDefinition.Teams) {
+				path := fmt.Sprintf("groupSelection.groups.%s.teams.%s", groupName, teamName)
+				teamDefinition, ok := supportDefinition.Teams[teamName]
+				if !ok {
+					errs = append(errs, fmt.Errorf("%s: unknown group team", path))
+					continue
+				}
+				errs = append(errs, validateDocumentMembers(path, storedSupportDefinition.Teams[teamName], teamDefinition.Members)...)
+			}
+		}
+
+		for _, member := range sortedKeys(document.Availability.Members) {
+			for i, period := range document.Availability.Members[member] {
+				if !period.From.Before(period.To) {
+					errs = append(errs, fmt.Errorf("availability.members.%s[%d]: from must be before to", member, i))
+				}
+			}
+		}
+	}
+
+	for i, pause := range document.Pauses.Pauses {
+		if options.Team != "" && !isTeamRotation(pause.Rotation, options.Team) {
+			continue
+		}
+		if !isConfiguredRotation(generalConfiguration, pause.Rotation) {
+			errs = append(errs, fmt.Errorf("pauses.pauses[%d]: unknown rotation %s", i, pause.Rotation))
+		}
+	}
+
+	for i, event := range document.History {
+		if !slices.Contains([]string{models.EventSelection, models.EventReplacement, models.EventSkip, models.EventReset}, event.Type) {
+			errs = append(errs, fmt.Errorf("history[%d].type: unknown event type %q", i, event.Type))
+		}
+		if event.Timestamp.IsZero() {
+			errs = append(errs, fmt.Errorf("history[%d].timestamp: missing", i))
+		}
+	}
+
+	return errs
+}
+
+func validateDocumentMembers(path string, members []string, configured []string) []error {
+	var errs []error
+	for i, member := range members {
+		if !slices.Contains(configured, member) {
+			errs = append(errs, fmt.Errorf("%s[%d]: %s is not a member of the rotation", path, i, member))
+		}
+	}
+
+	return errs
+}
+
+// teamDocument keeps the parts of the document that belong to a team.
+func teamDocument(document models.StateDocument, team string) models.StateDocument {
+	teamOnly := models.StateDocument{
+		SchemaVersion:     document.SchemaVersion,
+		ExportedAt:        document.ExportedAt,
+		TeamSelection:     models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)},
+		GroupSelection:    models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)},
+		History:           []models.HistoryEvent{},
+		MemberPreferences: models.MemberPreferencesStorage{Members: make(map[string]models.MemberPreferences)},
+		Availability:      models.AvailabilityStorage{Members: make(map[string][]models.AwayPeriod)},
+		Pauses:            models.PauseStorage{Pauses: []models.Pause{}},
+	}
+
+	if tasks, ok := document.TeamSelection.Teams[team]; ok {
+		teamOnly.TeamSelection.Teams[team] = tasks
+	}
+
+	for _, event := range document.History {
+		if isTeamRotation(event.Rotation, team) {
+			teamOnly.History = append(teamOnly.History, event)
+		}
+	}
+
+	for _, pause := range document.Pauses.Pauses {
+		if isTeamRotation(pause.Rotation, team) {
+			teamOnly.Pauses.Pauses = append(teamOnly.Pauses.Pauses, pause)
+		}
+	}
+
+	return teamOnly
+}
+
+func isTeamRotation(rotation models.RotationKey, team string) bool {
+	return rotation.Type == "teams" && rotation.Name == team
+}
+
+func sameEvent(a models.HistoryEvent, b models.HistoryEvent) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.Type == b.Type && a.Rotation == b.Rotation && slices.Equal(a.Members, b.Members)
+}
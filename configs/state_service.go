@@ -0,0 +1,294 @@
+package configs
+
+import (
+	"io.mt-borring.bot/models"
+	"log"
+	"sync"
+)
+
+// StateService owns the mutable bot state. Reads return copies and writes go through update
+// functions that run on a copy under the lock, which is persisted and only then applied, so a
+// failing update leaves the state untouched.
+//
+// Selections and member state (preferences, availability, pauses) have separate locks. A
+// selection update may read member state, member updates must never read selections.
+type StateService struct {
+	selectionMutex        sync.RWMutex
+	teamCurrentSelection  models.TeamCurrentSelection
+	groupCurrentSelection models.GroupCurrentSelection
+
+	memberMutex       sync.RWMutex
+	memberPreferences models.MemberPreferencesStorage
+	availability      models.AvailabilityStorage
+	pauses            models.PauseStorage
+}
+
+var state = &StateService{
+	teamCurrentSelection:  models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)},
+	groupCurrentSelection: models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)},
+	memberPreferences:     models.MemberPreferencesStorage{Members: make(map[string]models.MemberPreferences)},
+	availability:          models.AvailabilityStorage{Members: make(map[string][]models.AwayPeriod)},
+	pauses:                models.PauseStorage{Pauses: []models.Pause{}},
+}
+
+func State() *StateService {
+	return state
+}
+
+func (service *StateService) load(teamCurrentSelection models.TeamCurrentSelection, groupCurrentSelection models.GroupCurrentSelection,
+	memberPreferences models.MemberPreferencesStorage, availability models.AvailabilityStorage, pauses models.PauseStorage) {
+	service.selectionMutex.Lock()
+	service.teamCurrentSelection = teamCurrentSelection
+	service.groupCurrentSelection = groupCurrentSelection
+	service.selectionMutex.Unlock()
+
+	service.memberMutex.Lock()
+	service.memberPreferences = memberPreferences
+	service.availability = availability
+	service.pauses = pauses
+	service.memberMutex.Unlock()
+}
+
+func (service *StateService) TeamSelection() models.TeamCurrentSelection {
+	service.selectionMutex.RLock()
+	defer service.selectionMutex.RUnlock()
+
+	return service.teamCurrentSelection.Clone()
+}
+
+func (service *StateService) GroupSelection() models.GroupCurrentSelection {
+	service.selectionMutex.RLock()
+	defer service.selectionMutex.RUnlock()
+
+	return service.groupCurrentSelection.Clone()
+}
+
+func (service *StateService) TaskSelection(team string, task string) models.TaskSelection {
+	service.selectionMutex.RLock()
+	defer service.selectionMutex.RUnlock()
+
+	return service.teamCurrentSelection.Teams[team][task].Clone()
+}
+
+func (service *StateService) SupportSelection(group string) models.StoredSupportDefinition {
+	service.selectionMutex.RLock()
+	defer service.selectionMutex.RUnlock()
+
+	return service.groupCurrentSelection.Groups[group].Clone()
+}
+
+// UpdateTaskSelection applies the update to the selection of a single team task.
+func (service *StateService) UpdateTaskSelection(team string, task string, update func(taskSelection *models.TaskSelection) error) error {
+	service.selectionMutex.Lock()
+	defer service.selectionMutex.Unlock()
+
+	taskSelection := service.teamCurrentSelection.Teams[team][task].Clone()
+	if taskSelection.Members == nil {
+		taskSelection.Members = []string{}
+	}
+
+	if err := update(&taskSelection); err != nil {
+		return err
+	}
+
+	if err := backend.SaveTaskSelection(team, task, taskSelection); err != nil {
+		return err
+	}
+
+	if _, ok := service.teamCurrentSelection.Teams[team]; !ok {
+		service.teamCurrentSelection.Teams[team] = make(map[string]models.TaskSelection)
+	}
+	service.teamCurrentSelection.Teams[team][task] = taskSelection
+
+	return nil
+}
+
+// UpdateSupportSelection applies the update to the selection of a single group.
+func (service *StateService) UpdateSupportSelection(group string, update func(storedSupportDefinition *models.StoredSupportDefinition) error) error {
+	service.selectionMutex.Lock()
+	defer service.selectionMutex.Unlock()
+
+	storedSupportDefinition := service.groupCurrentSelection.Groups[group].Clone()
+	if storedSupportDefinition.Teams == nil {
+		storedSupportDefinition.Teams = make(map[string][]string)
+	}
+
+	if err := update(&storedSupportDefinition); err != nil {
+		return err
+	}
+
+	if err := backend.SaveSupportSelection(group, storedSupportDefinition); err != nil {
+		return err
+	}
+
+	service.groupCurrentSelection.Groups[group] = storedSupportDefinition
+
+	return nil
+}
+
+// UpdateSelections applies the update to every team and group selection at once.
+func (service *StateService) UpdateSelections(update func(teamCurrentSelection *models.TeamCurrentSelection, groupCurrentSelection *models.GroupCurrentSelection) error) error {
+	service.selectionMutex.Lock()
+	defer service.selectionMutex.Unlock()
+
+	teamCurrentSelection := service.teamCurrentSelection.Clone()
+	groupCurrentSelection := service.groupCurrentSelection.Clone()
+	if err := update(&teamCurrentSelection, &groupCurrentSelection); err != nil {
+		return err
+	}
+
+	if err := backend.SaveTeamSelection(teamCurrentSelection); err != nil {
+		return err
+	}
+
+	if err := backend.SaveGroupSelection(groupCurrentSelection); err != nil {
+		return err
+	}
+
+	service.teamCurrentSelection = teamCurrentSelection
+	service.groupCurrentSelection = groupCurrentSelection
+
+	return nil
+}
+
+func (service *StateService) MemberPreferences(member string) models.MemberPreferences {
+	service.memberMutex.RLock()
+	defer service.memberMutex.RUnlock()
+
+	return service.memberPreferences.Members[member].Clone()
+}
+
+func (service *StateService) AllMemberPreferences() models.MemberPreferencesStorage {
+	service.memberMutex.RLock()
+	defer service.memberMutex.RUnlock()
+
+	return service.memberPreferences.Clone()
+}
+
+func (service *StateService) UpdateMemberPreferences(update func(memberPreferences *models.MemberPreferencesStorage) error) error {
+	service.memberMutex.Lock()
+	defer service.memberMutex.Unlock()
+
+	memberPreferences := service.memberPreferences.Clone()
+	if err := update(&memberPreferences); err != nil {
+		return err
+	}
+
+	if err := backend.SaveMemberPreferences(memberPreferences); err != nil {
+		return err
+	}
+
+	service.memberPreferences = memberPreferences
+
+	return nil
+}
+
+func (service *StateService) Availability() models.AvailabilityStorage {
+	service.memberMutex.RLock()
+	defer service.memberMutex.RUnlock()
+
+	return service.availability.Clone()
+}
+
+func (service *StateService) UpdateAvailability(update func(availability *models.AvailabilityStorage) error) error {
+	service.memberMutex.Lock()
+	defer service.memberMutex.Unlock()
+
+	availability := service.availability.Clone()
+	if err := update(&availability); err != nil {
+		return err
+	}
+
+	if err := backend.SaveAvailability(availability); err != nil {
+		return err
+	}
+
+	service.availability = availability
+
+	return nil
+}
+
+func (service *StateService) Pauses() models.PauseStorage {
+	service.memberMutex.RLock()
+	defer service.memberMutex.RUnlock()
+
+	return service.pauses.Clone()
+}
+
+func (service *StateService) UpdatePauses(update func(pauses *models.PauseStorage) error) error {
+	service.memberMutex.Lock()
+	defer service.memberMutex.Unlock()
+
+	pauses := service.pauses.Clone()
+	if err := update(&pauses); err != nil {
+		return err
+	}
+
+	if err := backend.SavePauses(pauses); err != nil {
+		return err
+	}
+
+	service.pauses = pauses
+
+	return nil
+}
+
+// UpdateAll applies the update to the selections and the member state at once, given as a state
+// document without history. When persisting a part fails, the parts already persisted are written
+// back as they were, so the stored and the in-memory state stay unchanged.
+func (service *StateService) UpdateAll(update func(document *models.StateDocument) error) error {
+	service.selectionMutex.Lock()
+	defer service.selectionMutex.Unlock()
+	service.memberMutex.Lock()
+	defer service.memberMutex.Unlock()
+
+	current := models.StateDocument{
+		TeamSelection:     service.teamCurrentSelection,
+		GroupSelection:    service.groupCurrentSelection,
+		MemberPreferences: service.memberPreferences,
+		Availability:      service.availability,
+		Pauses:            service.pauses,
+	}
+	document := models.StateDocument{
+		TeamSelection:     service.teamCurrentSelection.Clone(),
+		GroupSelection:    service.groupCurrentSelection.Clone(),
+		MemberPreferences: service.memberPreferences.Clone(),
+		Availability:      service.availability.Clone(),
+		Pauses:            service.pauses.Clone(),
+	}
+	if err := update(&document); err != nil {
+		return err
+	}
+
+	if err := saveStateDocument(document); err != nil {
+		if restoreErr := saveStateDocument(current); restoreErr != nil {
+			log.Println("Error restoring the state after a failed update:", restoreErr)
+		}
+		return err
+	}
+
+	service.teamCurrentSelection = document.TeamSelection
+	service.groupCurrentSelection = document.GroupSelection
+	service.memberPreferences = document.MemberPreferences
+	service.availability = document.Availability
+	service.pauses = document.Pauses
+
+	return nil
+}
+
+func saveStateDocument(document models.StateDocument) error {
+	if err := backend.SaveTeamSelection(document.TeamSelection); err != nil {
+		return err
+	}
+	if err := backend.SaveGroupSelection(document.GroupSelection); err != nil {
+		return err
+	}
+	if err := backend.SaveMemberPreferences(document.MemberPreferences); err != nil {
+		return err
+	}
+	if err := backend.SaveAvailability(document.Availability); err != nil {
+		return err
+	}
+
+	return backend.SavePauses(document.Pauses)
+}
@@ -1,158 +1,113 @@
 package configs
 
 import (
-	"encoding/json"
-	"io"
 	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/storage"
 	"log"
 	"os"
+	"sync"
 )
 
+var generalDefinitionMutex sync.RWMutex
 var generalDefinition models.GeneralDefinition
-var teamCurrentSelection models.TeamCurrentSelection
-var groupCurrentSelection models.GroupCurrentSelection
+var backend storage.Backend
 
+// LoadAllConfigurations loads the general definition and the stored state when the bot starts,
+// recording the definition as a new configuration version when it changed since the last one.
 func LoadAllConfigurations() {
-	generalDefinition = loadGeneralDefinition()
-	teamCurrentSelection = loadTeamCurrentSelection()
-	groupCurrentSelection = loadGroupCurrentSelection()
-	defer SaveTeamSelectedUsers()
+	openBackend(storage.NewBackendFromEnv)
+	written := loadConfigurationAndState()
+	recordConfigSnapshot(written, models.ConfigAuthor{Name: "system"}, models.SnapshotLoad)
+	reconcileStateOnLoad(os.Getenv("RECONCILE_DRY_RUN") == "true")
+	ApplyMembershipChanges()
 }
 
-func loadGeneralDefinition() models.GeneralDefinition {
-	var generalConfiguration models.GeneralDefinition
-	file, err := os.Open("configuration.json")
-	if err != nil {
-		log.Println("Error opening file:", err)
-		// File does not exist or error reading the file, return empty structure
-		return models.GeneralDefinition{Teams: make(map[string]map[string]models.Task)}
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Println("Error closing General Definition file:", err)
-		}
-	}(file)
-
-	data, err := io.ReadAll(file)
-	if err != nil {
-		log.Println("Error reading file:", err)
-		return models.GeneralDefinition{Teams: make(map[string]map[string]models.Task)}
-	}
-
-	err = json.Unmarshal(data, &generalConfiguration)
-	if err != nil {
-		log.Println("Error parsing JSON:", err)
-		return models.GeneralDefinition{Teams: make(map[string]map[string]models.Task)}
-	}
-
-	return generalConfiguration
+// LoadConfigurationAndState loads the general definition and the stored state as they are, without
+// reconciling them, applying membership changes or recording a configuration version.
+func LoadConfigurationAndState() {
+	openBackend(storage.NewBackendFromEnv)
+	loadConfigurationAndState()
 }
 
-func loadTeamCurrentSelection() models.TeamCurrentSelection {
-	var currentSelectionStorage models.TeamCurrentSelection
-	file, err := os.Open("current_selection_storage.json")
+// LoadConfigurationAndStateReadOnly loads like LoadConfigurationAndState without migrating or
+// writing anything on disk, every change of the state is rejected. It is meant for the commands
+// that only inspect the state, like reconcile --dry-run and export.
+func LoadConfigurationAndStateReadOnly() {
+	openBackend(storage.NewReadOnlyBackendFromEnv)
+	loadConfigurationAndState()
+}
 
-	if err != nil {
-		log.Println("Error opening file:", err)
-		// File does not exist or error reading the file, return empty structure
-		return models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)}
+func openBackend(open func() (storage.Backend, error)) {
+	if backend != nil {
+		return
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Println("Error closing Team Current Selection file:", err)
-		}
-	}(file)
 
-	data, err := io.ReadAll(file)
+	openedBackend, err := open()
 	if err != nil {
-		// Error reading file, return empty structure
-		log.Println("Error reading file:", err)
-		return models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)}
+		log.Fatalln("Error opening storage backend:", err)
 	}
+	backend = openedBackend
+}
 
-	err = json.Unmarshal(data, &currentSelectionStorage)
-	if err != nil {
-		// Error parsing JSON, return empty structure
-		log.Println("Error parsing JSON:", err)
-		return models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)}
-	}
+// loadConfigurationAndState returns the general definition as written.
+func loadConfigurationAndState() models.GeneralDefinition {
+	written, generalConfiguration := loadGeneralDefinition()
+	setGeneralDefinition(generalConfiguration)
 
-	return currentSelectionStorage
-}
+	State().load(
+		loadState("team selections", backend.LoadTeamSelection),
+		loadState("group selections", backend.LoadGroupSelection),
+		loadState("member preferences", backend.LoadMemberPreferences),
+		loadState("availability", backend.LoadAvailability),
+		loadState("pauses", backend.LoadPauses),
+	)
 
-func loadGroupCurrentSelection() models.GroupCurrentSelection {
-	var currentSelectionStorage models.GroupCurrentSelection
-	file, err := os.Open("current_support_selection_storage.json")
+	return written
+}
 
-	if err != nil {
-		log.Println("Error opening file:", err)
-		// File does not exist or error reading the file, return empty structure
-		return models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)}
-	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Println("Error closing Group Current Selection file:", err)
+// loadGeneralDefinition reads and validates the configuration file, stopping the bot when it is
+// invalid instead of running with a partial or empty configuration. It returns the definition as
+// written and prepared for use.
+func loadGeneralDefinition() (models.GeneralDefinition, models.GeneralDefinition) {
+	path := configurationFile()
+	written, generalConfiguration, errs := loadConfigurationFile(path)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			log.Println("Invalid configuration:", err)
 		}
-	}(file)
-
-	data, err := io.ReadAll(file)
-	if err != nil {
-		// Error reading file, return empty structure
-		log.Println("Error reading file:", err)
-		return models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)}
+		log.Fatalf("%s has %d problem(s), fix them before starting Mr. Boring", path, len(errs))
 	}
 
-	err = json.Unmarshal(data, &currentSelectionStorage)
-	if err != nil {
-		// Error parsing JSON, return empty structure
-		log.Println("Error parsing JSON:", err)
-		return models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)}
+	for _, path := range unregisteredMembers(generalConfiguration) {
+		log.Println("Member missing from the members registry:", path)
 	}
 
-	return currentSelectionStorage
+	return written, generalConfiguration
 }
 
-func SaveTeamSelectedUsers() {
-	data, err := json.MarshalIndent(teamCurrentSelection, "", "  ")
-	if err != nil {
-		log.Println("Error marshalling selected users:", err)
-		return
-	}
+func setGeneralDefinition(generalConfiguration models.GeneralDefinition) {
+	generalDefinitionMutex.Lock()
+	defer generalDefinitionMutex.Unlock()
 
-	err = os.WriteFile("current_selection_storage.json", data, 0644)
-	if err != nil {
-		log.Println("Error writing selected users to file:", err)
-		return
-	}
+	generalDefinition = generalConfiguration
 }
 
-func SaveGroupSelectedUsers() {
-	data, err := json.MarshalIndent(groupCurrentSelection, "", "  ")
+// loadState reads a part of the stored state, stopping the bot when neither the file nor any of its
+// backups can be read, instead of starting empty and overwriting the state on the next change.
+func loadState[T any](name string, load func() (T, error)) T {
+	value, err := load()
 	if err != nil {
-		log.Println("Error marshalling selected users:", err)
-		return
+		log.Fatalf("Error loading the %s: %v. Restore it from a backup or remove it to start over", name, err)
 	}
 
-	err = os.WriteFile("current_support_selection_storage.json", data, 0644)
-	if err != nil {
-		log.Println("Error writing selected users to file:", err)
-		return
-	}
+	return value
 }
 
 func GetGeneralConfiguration() models.GeneralDefinition {
-	return generalDefinition
-}
-
-func GetTeamCurrentSelection() models.TeamCurrentSelection {
-	return teamCurrentSelection
-}
+	generalDefinitionMutex.RLock()
+	defer generalDefinitionMutex.RUnlock()
 
-func GetGroupCurrentSelection() models.GroupCurrentSelection {
-	return groupCurrentSelection
+	return generalDefinition
 }
 
 func GetMessageToPublish(teamTaskMessage string, taskName string) string {
@@ -170,43 +125,3 @@ func GetCronExpression(cronExpression string) string {
 
 	return GetGeneralConfiguration().DefaultCron
 }
-
-func AddUserToGroupSelection(supportTeam string, users map[string][]string) {
-	if _, ok := GetGroupCurrentSelection().Groups[supportTeam]; !ok {
-		GetGroupCurrentSelection().Groups[supportTeam] = models.StoredSupportDefinition{Teams: make(map[string][]string)}
-	}
-
-	for teamName, _ := range users {
-		if _, ok := GetGroupCurrentSelection().Groups[supportTeam].Teams[teamName]; !ok {
-			GetGroupCurrentSelection().Groups[supportTeam].Teams[teamName] = []string{}
-		}
-	}
-
-	for teamName, members := range users {
-		teamTask := GetGroupCurrentSelection().Groups[supportTeam].Teams[teamName]
-		teamTask = append(teamTask, members...)
-		// Add member to the existing task
-		GetGroupCurrentSelection().Groups[supportTeam].Teams[teamName] = teamTask
-	}
-
-	SaveGroupSelectedUsers()
-}
-
-func AddUserToTeamSelection(team string, task string, member string) {
-	if _, ok := GetTeamCurrentSelection().Teams[team]; !ok {
-		GetTeamCurrentSelection().Teams[team] = make(map[string]models.TaskSelection)
-	}
-
-	if _, ok := GetTeamCurrentSelection().Teams[team][task]; !ok {
-		GetTeamCurrentSelection().Teams[team][task] = models.TaskSelection{
-			Members: []string{},
-		}
-	}
-
-	teamTask := GetTeamCurrentSelection().Teams[team][task]
-	teamTask.Members = append(teamTask.Members, member)
-	// Add member to the existing task
-	GetTeamCurrentSelection().Teams[team][task] = teamTask
-
-	SaveTeamSelectedUsers()
-}
@@ -0,0 +1,312 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/robfig/cron"
+	"io.mt-borring.bot/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+var validAllocations = []string{"", AllocationEqual, AllocationProportional, AllocationRotating}
+var validJoinPolicies = []string{"", JoinPolicyImmediate, JoinPolicyNextCycle}
+var validDays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+var validShifts = []string{"morning", "afternoon"}
+
+// ValidateConfigurationFile reads the general definition file or directory and returns every problem found,
+// each one prefixed with the path of the offending value.
+func ValidateConfigurationFile(path string) []error {
+	_, errs := readGeneralDefinition(path)
+	return errs
+}
+
+// readGeneralDefinition parses the general definition file or directory and prepares it.
+func readGeneralDefinition(path string) (models.GeneralDefinition, []error) {
+	_, generalConfiguration, errs := loadConfigurationFile(path)
+	return generalConfiguration, errs
+}
+
+// loadConfigurationFile returns the general definition as written in the file or directory, and
+// prepared for use.
+func loadConfigurationFile(path string) (models.GeneralDefinition, models.GeneralDefinition, []error) {
+	written, errs := readConfiguration(path)
+	if len(errs) > 0 {
+		return written, written, errs
+	}
+
+	generalConfiguration, errs := prepareGeneralDefinition(written)
+	return written, generalConfiguration, errs
+}
+
+// prepareGeneralDefinition interpolates the environment variables, resolves the group references
+// and validates the result. It works on a copy, the given definition stays as written.
+func prepareGeneralDefinition(written models.GeneralDefinition) (models.GeneralDefinition, []error) {
+	generalConfiguration, err := cloneGeneralDefinition(written)
+	if err != nil {
+		return generalConfiguration, []error{err}
+	}
+
+	errs := interpolateGeneralDefinition(&generalConfiguration)
+	errs = append(errs, resolveGroupReferences(&generalConfiguration)...)
+	errs = append(errs, validateGeneralDefinition(generalConfiguration)...)
+
+	return generalConfiguration, errs
+}
+
+func cloneGeneralDefinition(generalConfiguration models.GeneralDefinition) (models.GeneralDefinition, error) {
+	var cloned models.GeneralDefinition
+	data, err := json.Marshal(generalConfiguration)
+	if err != nil {
+		return cloned, err
+	}
+
+	err = json.Unmarshal(data, &cloned)
+	return cloned, err
+}
+
+func validateGeneralDefinition(generalConfiguration models.GeneralDefinition) []error {
+	var errs []error
+
+	if generalConfiguration.DefaultCron != "" {
+		if _, err := cron.Parse(generalConfiguration.DefaultCron); err != nil {
+			errs = append(errs, fmt.Errorf("defaultCron: %v", err))
+		}
+	}
+
+	if len(generalConfiguration.Teams) == 0 && len(generalConfiguration.Groups) == 0 {
+		errs = append(errs, errors.New("teams: no teams or groups defined"))
+	}
+
+	for _, teamName := range sortedKeys(generalConfiguration.Teams) {
+		taskMap := generalConfiguration.Teams[teamName]
+		if len(taskMap) == 0 {
+			errs = append(errs, fmt.Errorf("teams.%s: no tasks defined", teamName))
+		}
+
+		for _, taskName := range sortedKeys(taskMap) {
+			task := taskMap[taskName]
+			path := fmt.Sprintf("teams.%s.%s", teamName, taskName)
+
+			errs = append(errs, validateCron(generalConfiguration, path, task.Cron)...)
+			errs = append(errs, validateMessage(generalConfiguration, path, task.Message, taskName)...)
+			errs = append(errs, validateMembers(path, task.Members)...)
+			errs = append(errs, validateAmount(path+".amount", task.Amount, len(task.Members))...)
+
+			if strings.TrimSpace(task.Channel) == "" {
+				errs = append(errs, fmt.Errorf("%s.channel: must not be empty", path))
+			}
+		}
+	}
+
+	for _, groupName := range sortedKeys(generalConfiguration.Groups) {
+		supportDefinition := generalConfiguration.Groups[groupName]
+		path := "groups." + groupName
+
+		errs = append(errs, validateCron(generalConfiguration, path, supportDefinition.Cron)...)
+		errs = append(errs, validateMessage(generalConfiguration, path, supportDefinition.Message, groupName)...)
+
+		if strings.TrimSpace(supportDefinition.Channel) == "" {
+			errs = append(errs, fmt.Errorf("%s.channel: must not be empty", path))
+		}
+
+		if len(supportDefinition.Teams) == 0 {
+			errs = append(errs, fmt.Errorf("%s.teams: no teams defined", path))
+		}
+
+		if !slices.Contains(validAllocations, supportDefinition.Allocation) {
+			errs = append(errs, fmt.Errorf("%s.allocation: unknown allocation %q, expected equal, proportional or rotating", path, supportDefinition.Allocation))
+		}
+
+		if supportDefinition.AmountFromEachTeam < 0 {
+			errs = append(errs, fmt.Errorf("%s.amountFromEachTeam: must not be negative", path))
+		}
+
+		totalMembers := 0
+		squadAmounts := worstSquadAllocation(supportDefinition)
+		for _, teamName := range sortedKeys(supportDefinition.Teams) {
+			teamDefinition := supportDefinition.Teams[teamName]
+			teamPath := fmt.Sprintf("%s.teams.%s", path, teamName)
+			totalMembers += len(teamDefinition.Members)
+
+			errs = append(errs, validateMembers(teamPath, teamDefinition.Members)...)
+
+			// The squad size decides the amounts, otherwise each team contributes its own amount
+			if supportDefinition.SquadSize > 0 {
+				if amount := squadAmounts[teamName]; amount > len(teamDefinition.Members) {
+					errs = append(errs, fmt.Errorf("%s.amount: %d of the squad of %d is more than the %d members", teamPath, amount, supportDefinition.SquadSize, len(teamDefinition.Members)))
+				}
+				continue
+			}
+
+			amount := teamDefinition.Amount
+			if amount == 0 {
+				amount = supportDefinition.AmountFromEachTeam
+			}
+			errs = append(errs, validateAmount(teamPath+".amount", amount, len(teamDefinition.Members))...)
+		}
+
+		if supportDefinition.SquadSize < 0 {
+			errs = append(errs, fmt.Errorf("%s.squadSize: must not be negative", path))
+		} else if supportDefinition.SquadSize > totalMembers {
+			errs = append(errs, fmt.Errorf("%s.squadSize: %d is more than the %d members of the group", path, supportDefinition.SquadSize, totalMembers))
+		}
+	}
+
+	for _, memberName := range sortedKeys(generalConfiguration.Members) {
+		member := generalConfiguration.Members[memberName]
+		if member.Timezone == "" {
+			continue
+		}
+
+		if _, err := time.LoadLocation(member.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("members.%s.timezone: unknown timezone %q", memberName, member.Timezone))
+		}
+	}
+
+	for _, memberName := range sortedKeys(generalConfiguration.Members) {
+		for i, role := range generalConfiguration.Members[memberName].Roles {
+			led, isLead := strings.CutPrefix(role, RoleLead+":")
+			if isLead && !isLeadable(generalConfiguration, led) {
+				errs = append(errs, fmt.Errorf("members.%s.roles[%d]: unknown team or group %q", memberName, i, led))
+			} else if !isLead && role != RoleMember && role != RoleAdmin {
+				errs = append(errs, fmt.Errorf("members.%s.roles[%d]: unknown role %q, expected %s, %s:<team or group> or %s", memberName, i, role, RoleMember, RoleLead, RoleAdmin))
+			}
+		}
+	}
+
+	for _, led := range sortedKeys(generalConfiguration.Permissions.LeadUsergroups) {
+		if !isLeadable(generalConfiguration, led) {
+			errs = append(errs, fmt.Errorf("permissions.leadUsergroups.%s: unknown team or group", led))
+		}
+	}
+
+	for _, memberName := range sortedKeys(generalConfiguration.Preferences) {
+		preferences := generalConfiguration.Preferences[memberName]
+		path := "preferences." + memberName
+
+		errs = append(errs, validatePreferenceValues(path+".excludedDays", preferences.ExcludedDays, validDays)...)
+		errs = append(errs, validatePreferenceValues(path+".preferredDays", preferences.PreferredDays, validDays)...)
+		errs = append(errs, validatePreferenceValues(path+".excludedShifts", preferences.ExcludedShifts, validShifts)...)
+		errs = append(errs, validatePreferenceValues(path+".preferredShifts", preferences.PreferredShifts, validShifts)...)
+	}
+
+	if !slices.Contains(validJoinPolicies, generalConfiguration.Onboarding.JoinPolicy) {
+		errs = append(errs, fmt.Errorf("onboarding.joinPolicy: unknown policy %q, expected %s or %s", generalConfiguration.Onboarding.JoinPolicy, JoinPolicyImmediate, JoinPolicyNextCycle))
+	}
+
+	return errs
+}
+
+// isLeadable tells whether a name is a team, a group or a team of a group.
+func isLeadable(generalConfiguration models.GeneralDefinition, name string) bool {
+	if _, ok := generalConfiguration.Teams[name]; ok {
+		return true
+	}
+	if _, ok := generalConfiguration.Groups[name]; ok {
+		return true
+	}
+
+	for _, supportDefinition := range generalConfiguration.Groups {
+		if _, ok := supportDefinition.Teams[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateCron(generalConfiguration models.GeneralDefinition, path string, cronExpression string) []error {
+	if cronExpression == "" {
+		if generalConfiguration.DefaultCron == "" {
+			return []error{fmt.Errorf("%s.cron: missing and there is no defaultCron", path)}
+		}
+		return nil
+	}
+
+	if _, err := cron.Parse(cronExpression); err != nil {
+		return []error{fmt.Errorf("%s.cron: %v", path, err)}
+	}
+
+	return nil
+}
+
+func validateMessage(generalConfiguration models.GeneralDefinition, path string, message string, messageKey string) []error {
+	if message != "" {
+		return nil
+	}
+
+	if _, ok := generalConfiguration.Messages[messageKey]; !ok {
+		return []error{fmt.Errorf("%s.message: empty and messages.%s does not exist", path, messageKey)}
+	}
+
+	return nil
+}
+
+func validateMembers(path string, members []string) []error {
+	if len(members) == 0 {
+		return []error{fmt.Errorf("%s.members: no members defined", path)}
+	}
+
+	var errs []error
+	seen := make(map[string]struct{}, len(members))
+	for i, member := range members {
+		if strings.TrimSpace(member) == "" {
+			errs = append(errs, fmt.Errorf("%s.members[%d]: empty member", path, i))
+			continue
+		}
+
+		if _, ok := seen[member]; ok {
+			errs = append(errs, fmt.Errorf("%s.members[%d]: duplicate member %q", path, i, member))
+		}
+		seen[member] = struct{}{}
+	}
+
+	return errs
+}
+
+func validateAmount(path string, amount int, members int) []error {
+	if amount < 1 {
+		return []error{fmt.Errorf("%s: must be at least 1", path)}
+	}
+
+	if amount > members {
+		return []error{fmt.Errorf("%s: %d is more than the %d members", path, amount, members)}
+	}
+
+	return nil
+}
+
+func validatePreferenceValues(path string, values []string, valid []string) []error {
+	var errs []error
+	for i, value := range values {
+		if !slices.Contains(valid, strings.ToLower(value)) {
+			errs = append(errs, fmt.Errorf("%s[%d]: unknown value %q, expected one of %s", path, i, value, strings.Join(valid, ", ")))
+		}
+	}
+
+	return errs
+}
+
+// describeJSONError points syntax and type errors to the line and column they happened at.
+func describeJSONError(path string, data []byte, err error) error {
+	var offset int64
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		offset = syntaxError.Offset
+	case errors.As(err, &typeError):
+		offset = typeError.Offset
+	default:
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+
+	return fmt.Errorf("%s:%d:%d: %v", path, line, column, err)
+}
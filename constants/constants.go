@@ -1,5 +0,0 @@
-package constants
-
-const (
-	SlackReplaceCommandRegex = `(selected|available)\s+(teams|groups)\s+([\p{L}\p{N}-]+)\s+([\p{L}\p{N}-]+)`
-)
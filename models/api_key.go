@@ -0,0 +1,20 @@
+package models
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// APIKey is a bearer token of the REST API. Scopes include each other, write can read and admin
+// can do everything.
+type APIKey struct {
+	Name  string
+	Key   string
+	Scope string
+}
+
+func (apiKey APIKey) Allows(scope string) bool {
+	scopes := map[string]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+	return scopes[apiKey.Scope] >= scopes[scope] && scopes[scope] > 0
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type AwayPeriod struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type AvailabilityStorage struct {
+	Members map[string][]AwayPeriod `json:"members"`
+}
+
+type Pause struct {
+	Rotation RotationKey `json:"rotation"`
+	Until    time.Time   `json:"until"`
+	Reason   string      `json:"reason"`
+}
+
+type PauseStorage struct {
+	Pauses []Pause `json:"pauses"`
+}
+
+func (storage AvailabilityStorage) Clone() AvailabilityStorage {
+	members := make(map[string][]AwayPeriod, len(storage.Members))
+	for member, periods := range storage.Members {
+		members[member] = append([]AwayPeriod{}, periods...)
+	}
+
+	return AvailabilityStorage{Members: members}
+}
+
+func (storage PauseStorage) Clone() PauseStorage {
+	return PauseStorage{Pauses: append([]Pause{}, storage.Pauses...)}
+}
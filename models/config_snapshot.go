@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	SnapshotLoad     = "load"
+	SnapshotAPI      = "api"
+	SnapshotRollback = "rollback"
+)
+
+// ConfigAuthor is who changed the configuration, the API key or Slack user it was changed with,
+// together with the note left with the change.
+type ConfigAuthor struct {
+	Name string
+	Note string
+}
+
+// ConfigSnapshot is a version of the general definition as it was written, before environment
+// variables are interpolated and group references resolved, so it can be restored as is.
+type ConfigSnapshot struct {
+	Version    int               `json:"version"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Author     string            `json:"author"`
+	Note       string            `json:"note,omitempty"`
+	Source     string            `json:"source"`
+	Diff       []string          `json:"diff"`
+	Definition GeneralDefinition `json:"definition"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type Exclusion struct {
+	Member string `json:"member"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
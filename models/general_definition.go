@@ -1,29 +1,52 @@
 package models
 
 type GeneralDefinition struct {
-	DefaultCron string                       `json:"defaultCron"`
-	Messages    map[string]string            `json:"messages"`
-	Teams       map[string]map[string]Task   `json:"teams"`
-	Groups      map[string]SupportDefinition `json:"groups"`
+	DefaultCron string                       `json:"defaultCron,omitempty" yaml:"defaultCron,omitempty" toml:"defaultCron,omitempty"`
+	Messages    map[string]string            `json:"messages,omitempty" yaml:"messages,omitempty" toml:"messages,omitempty,multiline"`
+	Teams       map[string]map[string]Task   `json:"teams,omitempty" yaml:"teams,omitempty" toml:"teams,omitempty"`
+	Groups      map[string]SupportDefinition `json:"groups,omitempty" yaml:"groups,omitempty" toml:"groups,omitempty"`
+	Preferences map[string]MemberPreferences `json:"preferences,omitempty" yaml:"preferences,omitempty" toml:"preferences,omitempty"`
+	Tags        map[string][]string          `json:"tags,omitempty" yaml:"tags,omitempty" toml:"tags,omitempty"`
+	Members     map[string]Member            `json:"members,omitempty" yaml:"members,omitempty" toml:"members,omitempty"`
+	Onboarding  OnboardingPolicy             `json:"onboarding,omitempty" yaml:"onboarding,omitempty" toml:"onboarding,omitempty"`
+	Permissions PermissionPolicy             `json:"permissions,omitempty" yaml:"permissions,omitempty" toml:"permissions,omitempty"`
+}
+
+type OnboardingPolicy struct {
+	JoinPolicy      string `json:"joinPolicy,omitempty" yaml:"joinPolicy,omitempty" toml:"joinPolicy,omitempty"`
+	WelcomeMessage  string `json:"welcomeMessage,omitempty" yaml:"welcomeMessage,omitempty" toml:"welcomeMessage,omitempty,multiline"`
+	FarewellMessage string `json:"farewellMessage,omitempty" yaml:"farewellMessage,omitempty" toml:"farewellMessage,omitempty,multiline"`
+}
+
+// PermissionPolicy grants roles to the members of Slack user groups, on top of the roles of the
+// members registry. Lead user groups are keyed by team or group name.
+type PermissionPolicy struct {
+	AdminUsergroup string            `json:"adminUsergroup,omitempty" yaml:"adminUsergroup,omitempty" toml:"adminUsergroup,omitempty"`
+	LeadUsergroups map[string]string `json:"leadUsergroups,omitempty" yaml:"leadUsergroups,omitempty" toml:"leadUsergroups,omitempty"`
 }
 
 type Task struct {
-	Cron    string   `json:"cron"`
-	Members []string `json:"members"`
-	Message string   `json:"message"`
-	Channel string   `json:"channel"`
-	Amount  int      `json:"amount"`
+	Cron    string   `json:"cron,omitempty" yaml:"cron,omitempty" toml:"cron,omitempty"`
+	Members []string `json:"members,omitempty" yaml:"members,omitempty" toml:"members,omitempty"`
+	Message string   `json:"message,omitempty" yaml:"message,omitempty" toml:"message,omitempty,multiline"`
+	Channel string   `json:"channel,omitempty" yaml:"channel,omitempty" toml:"channel,omitempty"`
+	Amount  int      `json:"amount,omitempty" yaml:"amount,omitempty" toml:"amount,omitempty"`
 }
 
 type SupportDefinition struct {
-	Cron               string                    `json:"cron"`
-	Teams              map[string]TeamDefinition `json:"teams"`
-	Message            string                    `json:"message"`
-	Channel            string                    `json:"channel"`
-	AmountFromEachTeam int                       `json:"amountFromEachTeam"`
+	Cron               string                    `json:"cron,omitempty" yaml:"cron,omitempty" toml:"cron,omitempty"`
+	Teams              map[string]TeamDefinition `json:"teams,omitempty" yaml:"teams,omitempty" toml:"teams,omitempty"`
+	Message            string                    `json:"message,omitempty" yaml:"message,omitempty" toml:"message,omitempty,multiline"`
+	Channel            string                    `json:"channel,omitempty" yaml:"channel,omitempty" toml:"channel,omitempty"`
+	AmountFromEachTeam int                       `json:"amountFromEachTeam,omitempty" yaml:"amountFromEachTeam,omitempty" toml:"amountFromEachTeam,omitempty"`
+	SquadSize          int                       `json:"squadSize,omitempty" yaml:"squadSize,omitempty" toml:"squadSize,omitempty"`
+	Allocation         string                    `json:"allocation,omitempty" yaml:"allocation,omitempty" toml:"allocation,omitempty"`
 }
 
 type TeamDefinition struct {
-	Members []string `json:"members"`
-	Amount  int      `json:"amount"`
+	Members []string `json:"members,omitempty" yaml:"members,omitempty" toml:"members,omitempty"`
+	Amount  int      `json:"amount,omitempty" yaml:"amount,omitempty" toml:"amount,omitempty"`
+	Team    string   `json:"team,omitempty" yaml:"team,omitempty" toml:"team,omitempty"`
+	Task    string   `json:"task,omitempty" yaml:"task,omitempty" toml:"task,omitempty"`
+	Tags    []string `json:"tags,omitempty" yaml:"tags,omitempty" toml:"tags,omitempty"`
 }
@@ -5,5 +5,39 @@ type GroupCurrentSelection struct {
 }
 
 type StoredSupportDefinition struct {
-	Teams map[string][]string `json:"teams"`
+	Teams          map[string][]string `json:"teams"`
+	RotationOffset int                 `json:"rotationOffset,omitempty"`
+	Rosters        map[string][]string `json:"rosters,omitempty"`
+	Pending        map[string][]string `json:"pending,omitempty"`
+}
+
+func (definition StoredSupportDefinition) Clone() StoredSupportDefinition {
+	return StoredSupportDefinition{
+		Teams:          cloneTeamMembers(definition.Teams),
+		RotationOffset: definition.RotationOffset,
+		Rosters:        cloneTeamMembers(definition.Rosters),
+		Pending:        cloneTeamMembers(definition.Pending),
+	}
+}
+
+func (selection GroupCurrentSelection) Clone() GroupCurrentSelection {
+	groups := make(map[string]StoredSupportDefinition, len(selection.Groups))
+	for group, definition := range selection.Groups {
+		groups[group] = definition.Clone()
+	}
+
+	return GroupCurrentSelection{Groups: groups}
+}
+
+func cloneTeamMembers(teams map[string][]string) map[string][]string {
+	if teams == nil {
+		return nil
+	}
+
+	cloned := make(map[string][]string, len(teams))
+	for team, members := range teams {
+		cloned[team] = cloneMembers(members)
+	}
+
+	return cloned
 }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	EventSelection   = "selection"
+	EventReplacement = "replacement"
+	EventSkip        = "skip"
+	EventReset       = "reset"
+
+	TriggerCron  = "cron"
+	TriggerSlash = "slash"
+	TriggerAPI   = "api"
+)
+
+type Trigger struct {
+	Source string
+	User   string
+}
+
+type HistoryEvent struct {
+	Timestamp   time.Time             `json:"timestamp"`
+	Type        string                `json:"type"`
+	Rotation    RotationKey           `json:"rotation"`
+	Members     []string              `json:"members"`
+	Trigger     string                `json:"trigger"`
+	TriggeredBy string                `json:"triggeredBy,omitempty"`
+	MessageTS   string                `json:"messageTs,omitempty"`
+	Reason      string                `json:"reason,omitempty"`
+	Explanation *SelectionExplanation `json:"explanation,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type Member struct {
+	SlackID  string   `json:"slackId,omitempty" yaml:"slackId,omitempty" toml:"slackId,omitempty"`
+	Email    string   `json:"email,omitempty" yaml:"email,omitempty" toml:"email,omitempty"`
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty" toml:"timezone,omitempty"`
+	Roles    []string `json:"roles,omitempty" yaml:"roles,omitempty" toml:"roles,omitempty"`
+	Active   *bool    `json:"active,omitempty" yaml:"active,omitempty" toml:"active,omitempty"`
+}
+
+// IsActive reports whether the member can be selected, members are active unless stated otherwise.
+func (member Member) IsActive() bool {
+	return member.Active == nil || *member.Active
+}
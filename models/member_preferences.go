@@ -0,0 +1,30 @@
+package models
+
+type MemberPreferences struct {
+	ExcludedDays    []string `json:"excludedDays,omitempty" yaml:"excludedDays,omitempty" toml:"excludedDays,omitempty"`
+	ExcludedShifts  []string `json:"excludedShifts,omitempty" yaml:"excludedShifts,omitempty" toml:"excludedShifts,omitempty"`
+	PreferredDays   []string `json:"preferredDays,omitempty" yaml:"preferredDays,omitempty" toml:"preferredDays,omitempty"`
+	PreferredShifts []string `json:"preferredShifts,omitempty" yaml:"preferredShifts,omitempty" toml:"preferredShifts,omitempty"`
+}
+
+type MemberPreferencesStorage struct {
+	Members map[string]MemberPreferences `json:"members"`
+}
+
+func (preferences MemberPreferences) Clone() MemberPreferences {
+	return MemberPreferences{
+		ExcludedDays:    cloneMembers(preferences.ExcludedDays),
+		ExcludedShifts:  cloneMembers(preferences.ExcludedShifts),
+		PreferredDays:   cloneMembers(preferences.PreferredDays),
+		PreferredShifts: cloneMembers(preferences.PreferredShifts),
+	}
+}
+
+func (storage MemberPreferencesStorage) Clone() MemberPreferencesStorage {
+	members := make(map[string]MemberPreferences, len(storage.Members))
+	for member, preferences := range storage.Members {
+		members[member] = preferences.Clone()
+	}
+
+	return MemberPreferencesStorage{Members: members}
+}
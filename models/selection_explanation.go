@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type RotationKey struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Task string `json:"task"`
+}
+
+func (key RotationKey) String() string {
+	return key.Type + " " + key.Name + " " + key.Task
+}
+
+type SelectionExplanation struct {
+	Timestamp        time.Time      `json:"timestamp"`
+	Rotation         RotationKey    `json:"rotation"`
+	Selected         []string       `json:"selected"`
+	Replaced         string         `json:"replaced,omitempty"`
+	EligiblePool     []string       `json:"eligiblePool"`
+	PreferenceScores map[string]int `json:"preferenceScores"`
+	Excluded         []Exclusion    `json:"excluded"`
+	CycleReset       bool           `json:"cycleReset"`
+	Strategy         string         `json:"strategy"`
+	Seed             int64          `json:"seed"`
+}
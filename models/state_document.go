@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// StateDocument is the whole bot state in a single document, used to move it between
+// environments.
+type StateDocument struct {
+	SchemaVersion     int                      `json:"schemaVersion"`
+	ExportedAt        time.Time                `json:"exportedAt"`
+	TeamSelection     TeamCurrentSelection     `json:"teamSelection"`
+	GroupSelection    GroupCurrentSelection    `json:"groupSelection"`
+	History           []HistoryEvent           `json:"history"`
+	MemberPreferences MemberPreferencesStorage `json:"memberPreferences"`
+	Availability      AvailabilityStorage      `json:"availability"`
+	Pauses            PauseStorage             `json:"pauses"`
+}
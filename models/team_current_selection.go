@@ -6,4 +6,34 @@ type TeamCurrentSelection struct {
 
 type TaskSelection struct {
 	Members []string `json:"members"`
+	Roster  []string `json:"roster,omitempty"`
+	Pending []string `json:"pending,omitempty"`
+}
+
+func (selection TaskSelection) Clone() TaskSelection {
+	return TaskSelection{
+		Members: cloneMembers(selection.Members),
+		Roster:  cloneMembers(selection.Roster),
+		Pending: cloneMembers(selection.Pending),
+	}
+}
+
+func (selection TeamCurrentSelection) Clone() TeamCurrentSelection {
+	teams := make(map[string]map[string]TaskSelection, len(selection.Teams))
+	for team, tasks := range selection.Teams {
+		teams[team] = make(map[string]TaskSelection, len(tasks))
+		for task, taskSelection := range tasks {
+			teams[team][task] = taskSelection.Clone()
+		}
+	}
+
+	return TeamCurrentSelection{Teams: teams}
+}
+
+func cloneMembers(members []string) []string {
+	if members == nil {
+		return nil
+	}
+
+	return append([]string{}, members...)
 }
@@ -0,0 +1,179 @@
+package selection
+
+import (
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"io.mt-borring.bot/utils"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ReasonServed   = "served"
+	ReasonCooldown = "cooldown"
+	ReasonConflict = "conflict"
+	ReasonInactive = "inactive"
+	ReasonJoining  = "joining"
+	ReasonOOO      = "ooo"
+
+	ShiftMorning   = "morning"
+	ShiftAfternoon = "afternoon"
+)
+
+// Shift returns the shift a selection made at the given time belongs to.
+func Shift(at time.Time) string {
+	if at.Hour() < 12 {
+		return ShiftMorning
+	}
+
+	return ShiftAfternoon
+}
+
+// Eligibility splits the members of a rotation into the ones that can be selected at the given
+// time and the ones that cannot, together with the reason they were excluded.
+func Eligibility(rotation models.RotationKey, members []string, served []string, at time.Time) ([]string, []models.Exclusion) {
+	return eligibility(members, served, configs.GetPendingMembers(rotation), at)
+}
+
+func eligibility(members []string, served []string, pending []string, at time.Time) ([]string, []models.Exclusion) {
+	var eligible []string
+	var excluded []models.Exclusion
+	for _, member := range members {
+		if slices.Contains(served, member) {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonServed, Detail: "already served this cycle"})
+			continue
+		}
+
+		if slices.Contains(pending, member) {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonJoining, Detail: "waits for the next cycle"})
+			continue
+		}
+
+		if registeredMember, ok := configs.GetMember(member); ok && !registeredMember.IsActive() {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonInactive, Detail: "inactive"})
+			continue
+		}
+
+		if period, ok := configs.GetAwayPeriod(member, at); ok {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonOOO, Detail: "out of office, back on " + period.To.Format("2006-01-02")})
+			continue
+		}
+
+		if detail, ok := hardExclusion(member, at); ok {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonConflict, Detail: detail})
+			continue
+		}
+
+		eligible = append(eligible, member)
+	}
+
+	return eligible, excluded
+}
+
+// cooldown keeps the members selected in the last turn of the previous cycle out of the first draw
+// of a new cycle, so nobody is selected twice in a row, as long as enough members stay eligible.
+func cooldown(eligible []string, excluded []models.Exclusion, served []string, amount int) ([]string, []models.Exclusion) {
+	lastTurn := served[max(0, len(served)-amount):]
+	remaining := slices.DeleteFunc(slices.Clone(eligible), func(member string) bool {
+		return slices.Contains(lastTurn, member)
+	})
+	if len(remaining) < amount || len(remaining) == len(eligible) {
+		return eligible, excluded
+	}
+
+	for _, member := range eligible {
+		if slices.Contains(lastTurn, member) {
+			excluded = append(excluded, models.Exclusion{Member: member, Reason: ReasonCooldown, Detail: "selected in the last turn of the previous cycle"})
+		}
+	}
+
+	return remaining, excluded
+}
+
+// eligibleAfterReset returns the members that could be selected once the cycle is reset,
+// which also onboards the members waiting for the next cycle.
+func eligibleAfterReset(members []string, at time.Time) []string {
+	eligible, _ := eligibility(members, nil, nil, at)
+	return eligible
+}
+
+// Rank shuffles the members with the given seed and then moves the ones whose soft preferences
+// match the given time to the front, so they are picked first whenever possible. It returns the
+// preference scores used, which are needed to replay the draw.
+func Rank(members []string, at time.Time, seed int64) map[string]int {
+	scores := make(map[string]int, len(members))
+	for _, member := range members {
+		scores[member] = preferenceScore(member, at)
+	}
+
+	rankWithScores(members, scores, seed)
+	return scores
+}
+
+func rankWithScores(members []string, scores map[string]int, seed int64) {
+	utils.ShuffleWithSeed(members, seed)
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return scores[members[i]] > scores[members[j]]
+	})
+}
+
+// memberTime converts the time to the member's timezone, so days and shifts are the member's own.
+func memberTime(member string, at time.Time) time.Time {
+	registeredMember, ok := configs.GetMember(member)
+	if !ok || registeredMember.Timezone == "" {
+		return at
+	}
+
+	location, err := time.LoadLocation(registeredMember.Timezone)
+	if err != nil {
+		log.Printf("Invalid timezone %s for member %s: %v", registeredMember.Timezone, member, err)
+		return at
+	}
+
+	return at.In(location)
+}
+
+func hardExclusion(member string, at time.Time) (string, bool) {
+	preferences := configs.GetMemberPreferences(member)
+	at = memberTime(member, at)
+
+	day := strings.ToLower(at.Weekday().String())
+	if slices.Contains(preferences.ExcludedDays, day) {
+		return "excluded on " + at.Weekday().String(), true
+	}
+
+	shift := Shift(at)
+	if slices.Contains(preferences.ExcludedShifts, shift) {
+		return "excluded from " + shift + " shifts", true
+	}
+
+	return "", false
+}
+
+func preferenceScore(member string, at time.Time) int {
+	preferences := configs.GetMemberPreferences(member)
+	at = memberTime(member, at)
+	score := 0
+
+	if len(preferences.PreferredDays) > 0 {
+		if slices.Contains(preferences.PreferredDays, strings.ToLower(at.Weekday().String())) {
+			score++
+		} else {
+			score--
+		}
+	}
+
+	if len(preferences.PreferredShifts) > 0 {
+		if slices.Contains(preferences.PreferredShifts, Shift(at)) {
+			score++
+		} else {
+			score--
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,42 @@
+package selection
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCooldown(t *testing.T) {
+	tests := []struct {
+		name     string
+		eligible []string
+		served   []string
+		amount   int
+		want     []string
+		cooling  []string
+	}{
+		{name: "last turn sits out", eligible: []string{"ana", "maria", "fabio"}, served: []string{"fabio", "maria"}, amount: 1, want: []string{"ana", "fabio"}, cooling: []string{"maria"}},
+		{name: "last turn of several members", eligible: []string{"ana", "maria", "fabio", "rita"}, served: []string{"ana", "maria", "fabio"}, amount: 2, want: []string{"ana", "rita"}, cooling: []string{"maria", "fabio"}},
+		{name: "not enough members otherwise", eligible: []string{"ana", "maria"}, served: []string{"ana", "maria"}, amount: 2, want: []string{"ana", "maria"}},
+		{name: "nothing served", eligible: []string{"ana", "maria"}, amount: 1, want: []string{"ana", "maria"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			eligible, excluded := cooldown(test.eligible, nil, test.served, test.amount)
+			if !slices.Equal(eligible, test.want) {
+				t.Errorf("eligible %v, want %v", eligible, test.want)
+			}
+
+			var cooling []string
+			for _, exclusion := range excluded {
+				if exclusion.Reason != ReasonCooldown {
+					t.Errorf("%s excluded because of %s", exclusion.Member, exclusion.Reason)
+				}
+				cooling = append(cooling, exclusion.Member)
+			}
+			if !slices.Equal(cooling, test.cooling) {
+				t.Errorf("cooling down %v, want %v", cooling, test.cooling)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package selection
+
+import (
+	"io.mt-borring.bot/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StrategyRanked      = "random, soft preferences first"
+	StrategyReplacement = "replacement, soft preferences first"
+	StrategySkip        = "skip until the next cycle, soft preferences first"
+)
+
+func explain(rotation models.RotationKey, at time.Time, selected []string, pool []string, scores map[string]int, excluded []models.Exclusion, strategy string, seed int64) models.SelectionExplanation {
+	return models.SelectionExplanation{
+		Timestamp:        at,
+		Rotation:         rotation,
+		Selected:         append([]string{}, selected...),
+		EligiblePool:     pool,
+		PreferenceScores: scores,
+		Excluded:         excluded,
+		Strategy:         strategy,
+		Seed:             seed,
+	}
+}
+
+// Replay draws again from the recorded eligible pool with the recorded seed and preference
+// scores, returning the members that selection would pick.
+func Replay(explanation models.SelectionExplanation) []string {
+	members := append([]string{}, explanation.EligiblePool...)
+	rankWithScores(members, explanation.PreferenceScores, explanation.Seed)
+
+	if len(explanation.Selected) > len(members) {
+		return members
+	}
+
+	return members[:len(explanation.Selected)]
+}
+
+// Describe renders an explanation as a human readable Slack message.
+func Describe(explanation models.SelectionExplanation) string {
+	var builder strings.Builder
+
+	builder.WriteString("Selected " + strings.Join(explanation.Selected, ", "))
+	if explanation.Replaced != "" {
+		builder.WriteString(" to replace " + explanation.Replaced)
+	}
+	builder.WriteString(" for " + explanation.Rotation.String())
+	builder.WriteString(" on " + explanation.Timestamp.Format("2006-01-02 15:04") + "\n")
+	builder.WriteString("Strategy: " + explanation.Strategy + " (seed " + strconv.FormatInt(explanation.Seed, 10) + ")\n")
+	if explanation.CycleReset {
+		builder.WriteString("Everyone had already served, so a new cycle was started\n")
+	}
+	builder.WriteString("Eligible pool: " + strings.Join(explanation.EligiblePool, ", "))
+
+	if len(explanation.Excluded) > 0 {
+		var excluded []string
+		for _, exclusion := range explanation.Excluded {
+			excluded = append(excluded, exclusion.Member+" ("+exclusion.Detail+")")
+		}
+		builder.WriteString("\nExcluded: " + strings.Join(excluded, ", "))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,104 @@
+package selection
+
+import (
+	"fmt"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"log"
+	"time"
+)
+
+// SelectUsersForSupport selects the squad of a group and announces it, returning an ErrSkipped
+// error when the selection did not happen.
+func SelectUsersForSupport(supportName string, supportDefinition models.SupportDefinition, trigger models.Trigger) error {
+	log.Println("Selecting users for support --> ", supportName)
+
+	now := time.Now()
+	groupRotation := models.RotationKey{Type: "groups", Name: supportName}
+	if _, paused := configs.GetPause(groupRotation, now); paused {
+		log.Printf("Support %s is paused, skipping selection", supportName)
+		return recordSkip(groupRotation, trigger, "paused")
+	}
+
+	var userNames []string
+	var explanations []models.SelectionExplanation
+	var servedBeforeReset map[string][]string
+	err := configs.State().UpdateSupportSelection(supportName, func(storedSupportDefinition *models.StoredSupportDefinition) error {
+		userNames = nil
+		explanations = nil
+		servedBeforeReset = make(map[string][]string)
+
+		amounts := configs.AllocateSquad(supportDefinition, storedSupportDefinition.RotationOffset)
+		for _, teamName := range sortedTeamNames(supportDefinition) {
+			teamDefinition := supportDefinition.Teams[teamName]
+			amount := amounts[teamName]
+
+			if len(teamDefinition.Members) < amount {
+				return fmt.Errorf("not enough members of %s to select", teamName)
+			}
+
+			if amount == 0 {
+				log.Printf("No members of %s to select for support %s", teamName, supportName)
+				continue
+			}
+
+			if len(eligibleAfterReset(teamDefinition.Members, now)) < amount {
+				return fmt.Errorf("not enough eligible members of %s to select", teamName)
+			}
+
+			rotation := models.RotationKey{Type: "groups", Name: supportName, Task: teamName}
+			availableMembers, excluded := eligibility(teamDefinition.Members, storedSupportDefinition.Teams[teamName], storedSupportDefinition.Pending[teamName], now)
+			cycleReset := false
+			if len(availableMembers) < amount {
+				log.Printf("Not enough members of %s to select for support %s. Resetting...", teamName, supportName)
+				servedBeforeReset[teamName] = storedSupportDefinition.Teams[teamName]
+				storedSupportDefinition.Teams[teamName] = []string{}
+				delete(storedSupportDefinition.Pending, teamName)
+				availableMembers, excluded = eligibility(teamDefinition.Members, nil, nil, now)
+				availableMembers, excluded = cooldown(availableMembers, excluded, servedBeforeReset[teamName], amount)
+				cycleReset = true
+			}
+
+			pool := append([]string{}, availableMembers...)
+			seed := newSeed()
+			scores := Rank(availableMembers, now, seed)
+
+			selectedMembers := availableMembers[:amount]
+			storedSupportDefinition.Teams[teamName] = append(storedSupportDefinition.Teams[teamName], selectedMembers...)
+			userNames = append(userNames, selectedMembers...)
+
+			explanation := explain(rotation, now, selectedMembers, pool, scores, excluded, StrategyRanked+", "+describeAllocation(supportDefinition), seed)
+			explanation.CycleReset = cycleReset
+			explanations = append(explanations, explanation)
+		}
+
+		if supportDefinition.SquadSize > 0 && supportDefinition.Allocation == configs.AllocationRotating && len(supportDefinition.Teams) > 0 {
+			storedSupportDefinition.RotationOffset = (storedSupportDefinition.RotationOffset + supportDefinition.SquadSize) % len(supportDefinition.Teams)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error selecting users for support %s: %v", supportName, err)
+		return recordSkip(groupRotation, trigger, err.Error())
+	}
+
+	for _, explanation := range explanations {
+		if explanation.CycleReset {
+			recordReset(explanation.Rotation, trigger, servedBeforeReset[explanation.Rotation.Task])
+		}
+	}
+
+	mentions := configs.Mentions(userNames)
+
+	log.Printf("Selected users for support %s :: %s\n", supportName, mentions)
+	message := configs.GetMessageToPublish(supportDefinition.Message, supportName)
+	messageTS := configs.SendMessageToSlack(message, mentions, supportDefinition.Channel, supportName)
+	configs.UpdateSlackGroup(userNames, supportName)
+
+	for _, explanation := range explanations {
+		recordSelection(models.EventSelection, explanation, trigger, messageTS)
+	}
+
+	return nil
+}
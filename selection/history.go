@@ -0,0 +1,49 @@
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+)
+
+var ErrSkipped = errors.New("selection skipped")
+
+// recordSkip records why a selection did not happen and returns it as an ErrSkipped error.
+func recordSkip(rotation models.RotationKey, trigger models.Trigger, reason string) error {
+	configs.RecordEvent(models.HistoryEvent{
+		Type:        models.EventSkip,
+		Rotation:    rotation,
+		Members:     []string{},
+		Trigger:     trigger.Source,
+		TriggeredBy: trigger.User,
+		Reason:      reason,
+	})
+
+	return fmt.Errorf("%w: %s", ErrSkipped, reason)
+}
+
+// recordReset records the members that had served when the cycle of the rotation started over.
+func recordReset(rotation models.RotationKey, trigger models.Trigger, served []string) {
+	configs.RecordEvent(models.HistoryEvent{
+		Type:        models.EventReset,
+		Rotation:    rotation,
+		Members:     served,
+		Trigger:     trigger.Source,
+		TriggeredBy: trigger.User,
+		Reason:      "everyone eligible had served",
+	})
+}
+
+func recordSelection(eventType string, explanation models.SelectionExplanation, trigger models.Trigger, messageTS string) {
+	configs.RecordEvent(models.HistoryEvent{
+		Timestamp:   explanation.Timestamp,
+		Type:        eventType,
+		Rotation:    explanation.Rotation,
+		Members:     explanation.Selected,
+		Trigger:     trigger.Source,
+		TriggeredBy: trigger.User,
+		MessageTS:   messageTS,
+		Explanation: &explanation,
+	})
+}
@@ -0,0 +1,28 @@
+package selection
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var randomMutex sync.Mutex
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// SetRandomSource replaces the source every selection draws its seed from. Using a fixed
+// source makes the whole sequence of selections reproducible.
+func SetRandomSource(source rand.Source) {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+
+	random = rand.New(source)
+}
+
+// newSeed returns the seed of the next draw. It is recorded with the selection so the draw
+// can be replayed later on.
+func newSeed() int64 {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+
+	return random.Int63()
+}
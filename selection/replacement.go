@@ -0,0 +1,124 @@
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"log"
+	"slices"
+	"time"
+)
+
+var ErrNobodyToReplace = errors.New("nobody is currently selected")
+var ErrNoReplacement = errors.New("nobody is available to take over")
+var ErrNotSelected = errors.New("not selected in the current cycle")
+
+// ReplaceMember hands the selection of the replaced member over to somebody else that has not
+// served in the current cycle. The replaced member must have been selected in the current cycle.
+func ReplaceMember(rotation models.RotationKey, replaced string, trigger models.Trigger) (string, error) {
+	return handOver(rotation, replaced, trigger, false)
+}
+
+// SkipMember hands the selection of the member over like ReplaceMember, and keeps the member out of
+// the rest of the cycle, together with the members waiting for the next cycle.
+func SkipMember(rotation models.RotationKey, member string, trigger models.Trigger) (string, error) {
+	return handOver(rotation, member, trigger, true)
+}
+
+func handOver(rotation models.RotationKey, replaced string, trigger models.Trigger, skip bool) (string, error) {
+	if rotation.Type == "groups" {
+		members := configs.GetGeneralConfiguration().Groups[rotation.Name].Teams[rotation.Task].Members
+
+		var newMember string
+		var explanation models.SelectionExplanation
+		err := configs.State().UpdateSupportSelection(rotation.Name, func(storedSupportDefinition *models.StoredSupportDefinition) error {
+			var err error
+			newMember, explanation, err = pickReplacement(rotation, members, storedSupportDefinition.Teams[rotation.Task], storedSupportDefinition.Pending[rotation.Task], replaced)
+			if err != nil {
+				return err
+			}
+
+			storedSupportDefinition.Teams[rotation.Task] = replaceMember(storedSupportDefinition.Teams[rotation.Task], replaced, newMember)
+			if skip {
+				if storedSupportDefinition.Pending == nil {
+					storedSupportDefinition.Pending = make(map[string][]string)
+				}
+				storedSupportDefinition.Pending[rotation.Task] = append(storedSupportDefinition.Pending[rotation.Task], replaced)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		recordHandOver(explanation, trigger, skip)
+		return newMember, nil
+	}
+
+	members := configs.GetGeneralConfiguration().Teams[rotation.Name][rotation.Task].Members
+
+	var newMember string
+	var explanation models.SelectionExplanation
+	err := configs.State().UpdateTaskSelection(rotation.Name, rotation.Task, func(taskSelection *models.TaskSelection) error {
+		var err error
+		newMember, explanation, err = pickReplacement(rotation, members, taskSelection.Members, taskSelection.Pending, replaced)
+		if err != nil {
+			return err
+		}
+
+		taskSelection.Members = replaceMember(taskSelection.Members, replaced, newMember)
+		if skip {
+			taskSelection.Pending = append(taskSelection.Pending, replaced)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	recordHandOver(explanation, trigger, skip)
+	return newMember, nil
+}
+
+func recordHandOver(explanation models.SelectionExplanation, trigger models.Trigger, skip bool) {
+	if skip {
+		explanation.Strategy = StrategySkip
+	}
+
+	recordSelection(models.EventReplacement, explanation, trigger, "")
+}
+
+func pickReplacement(rotation models.RotationKey, members []string, served []string, pending []string, replaced string) (string, models.SelectionExplanation, error) {
+	if len(served) == 0 {
+		return "", models.SelectionExplanation{}, ErrNobodyToReplace
+	}
+	if !slices.Contains(served, replaced) {
+		return "", models.SelectionExplanation{}, fmt.Errorf("%s was %w", replaced, ErrNotSelected)
+	}
+
+	now := time.Now()
+	availableMembers, excluded := eligibility(members, served, pending, now)
+	if len(availableMembers) == 0 {
+		log.Println("Not enough members to select, falling back to the members joining at the next cycle")
+		availableMembers, excluded = eligibility(members, served, nil, now)
+	}
+
+	if len(availableMembers) == 0 {
+		return "", models.SelectionExplanation{}, ErrNoReplacement
+	}
+
+	pool := append([]string{}, availableMembers...)
+	seed := newSeed()
+	scores := Rank(availableMembers, now, seed)
+
+	explanation := explain(rotation, now, availableMembers[:1], pool, scores, excluded, StrategyReplacement, seed)
+	explanation.Replaced = replaced
+
+	return availableMembers[0], explanation, nil
+}
+
+func replaceMember(members []string, replaced string, newMember string) []string {
+	members[slices.Index(members, replaced)] = newMember
+	return members
+}
@@ -0,0 +1,95 @@
+package selection
+
+import (
+	"errors"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+const raceConfiguration = `{
+    "defaultCron": "0 0 9 * * 1-5",
+    "teams": {
+        "payments-zeus": {
+            "daily": {
+                "members": ["ana", "maria", "fabio", "pedro", "andre", "francisco", "joana", "rita"],
+                "message": "{{name}} hosts the daily",
+                "channel": "payments-zeus",
+                "amount": 1
+            }
+        }
+    }
+}`
+
+// TestReplaceMemberConcurrentWithSelection runs replacements while new selections are made, which
+// must leave the stored selection free of duplicates and in sync with the file. Run it with -race.
+func TestReplaceMemberConcurrentWithSelection(t *testing.T) {
+	directory := t.TempDir()
+	configurationPath := filepath.Join(directory, "configuration.json")
+	if err := os.WriteFile(configurationPath, []byte(raceConfiguration), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_PATH", configurationPath)
+	t.Setenv("DATA_DIR", filepath.Join(directory, "data"))
+	t.Setenv("STORAGE_BACKEND", "")
+
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true, "channel": "C123", "ts": "1700000000.000100"}`))
+	}))
+	defer slackServer.Close()
+	t.Setenv("SLACK_API_URL", slackServer.URL+"/")
+	configs.InitSlackApi()
+	configs.LoadConfigurationAndState()
+
+	rotation := models.RotationKey{Type: "teams", Name: "payments-zeus", Task: "daily"}
+	trigger := models.Trigger{Source: models.TriggerSlash, User: "U123"}
+	if err := SelectUserForTask(rotation.Name, rotation.Task, trigger); err != nil {
+		t.Fatalf("first selection: %v", err)
+	}
+
+	var wait sync.WaitGroup
+	wait.Add(2)
+	go func() {
+		defer wait.Done()
+		for i := 0; i < 20; i++ {
+			if err := SelectUserForTask(rotation.Name, rotation.Task, trigger); err != nil {
+				t.Errorf("selection: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wait.Done()
+		for i := 0; i < 20; i++ {
+			members := configs.State().TaskSelection(rotation.Name, rotation.Task).Members
+			if len(members) == 0 {
+				continue
+			}
+
+			_, err := ReplaceMember(rotation, members[len(members)-1], trigger)
+			if err != nil && !errors.Is(err, ErrNotSelected) && !errors.Is(err, ErrNobodyToReplace) && !errors.Is(err, ErrNoReplacement) {
+				t.Errorf("replacement: %v", err)
+			}
+		}
+	}()
+	wait.Wait()
+
+	members := configs.State().TaskSelection(rotation.Name, rotation.Task).Members
+	for i, member := range members {
+		if slices.Contains(members[i+1:], member) {
+			t.Errorf("%s was selected twice in the same cycle: %v", member, members)
+		}
+	}
+
+	configs.LoadConfigurationAndState()
+	stored := configs.State().TaskSelection(rotation.Name, rotation.Task).Members
+	if !slices.Equal(stored, members) {
+		t.Errorf("stored selection %v, want %v", stored, members)
+	}
+}
@@ -0,0 +1,58 @@
+package selection
+
+import (
+	"github.com/robfig/cron"
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"log"
+	"sync"
+)
+
+var (
+	schedulerMutex sync.Mutex
+	scheduler      *cron.Cron
+)
+
+// StartScheduler schedules the selection of every team task and group of the general definition.
+func StartScheduler() {
+	ReloadScheduler()
+}
+
+// ReloadScheduler replaces the scheduled selections with the ones of the current general
+// definition, so configuration changes apply without a restart.
+func ReloadScheduler() {
+	schedulerMutex.Lock()
+	defer schedulerMutex.Unlock()
+
+	c := cron.New()
+
+	log.Println("Starting to process Teams section...")
+	for teamName, taskMap := range configs.GetGeneralConfiguration().Teams {
+		for taskName, task := range taskMap {
+			err := c.AddFunc(configs.GetCronExpression(task.Cron), func() {
+				SelectUserForTask(teamName, taskName, models.Trigger{Source: models.TriggerCron})
+			})
+
+			if err != nil {
+				log.Println("Error scheduling task:", err)
+			}
+		}
+	}
+
+	log.Println("Starting to process Groups section...")
+	for supportName, supportDefinition := range configs.GetGeneralConfiguration().Groups {
+		err := c.AddFunc(configs.GetCronExpression(supportDefinition.Cron), func() {
+			SelectUsersForSupport(supportName, supportDefinition, models.Trigger{Source: models.TriggerCron})
+		})
+
+		if err != nil {
+			log.Println("Error scheduling Support:", err)
+		}
+	}
+
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	scheduler = c
+	scheduler.Start()
+}
@@ -0,0 +1,31 @@
+package selection
+
+import (
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"sort"
+	"strconv"
+)
+
+func describeAllocation(supportDefinition models.SupportDefinition) string {
+	if supportDefinition.SquadSize == 0 {
+		return "fixed amount per team"
+	}
+
+	allocation := supportDefinition.Allocation
+	if allocation == "" {
+		allocation = configs.AllocationEqual
+	}
+
+	return allocation + " allocation of a squad of " + strconv.Itoa(supportDefinition.SquadSize)
+}
+
+func sortedTeamNames(supportDefinition models.SupportDefinition) []string {
+	teamNames := make([]string, 0, len(supportDefinition.Teams))
+	for teamName := range supportDefinition.Teams {
+		teamNames = append(teamNames, teamName)
+	}
+	sort.Strings(teamNames)
+
+	return teamNames
+}
@@ -0,0 +1,84 @@
+package selection
+
+import (
+	"io.mt-borring.bot/configs"
+	"io.mt-borring.bot/models"
+	"log"
+	"time"
+)
+
+// SelectUserForTask selects the members of a task and announces them, returning an ErrSkipped
+// error when the selection did not happen.
+func SelectUserForTask(teamName string, taskName string, trigger models.Trigger) error {
+	log.Println("Selecting user for task", taskName)
+
+	task := configs.GetGeneralConfiguration().Teams[teamName][taskName]
+	rotation := models.RotationKey{Type: "teams", Name: teamName, Task: taskName}
+	membersToSelect := task.Amount
+	if membersToSelect == 0 {
+		log.Println("No members to select for task ", taskName)
+		return recordSkip(rotation, trigger, "no members to select")
+	}
+
+	if len(task.Members) < membersToSelect {
+		log.Println("Not enough members to select for task ", taskName)
+		return recordSkip(rotation, trigger, "not enough members")
+	}
+
+	now := time.Now()
+	if _, paused := configs.GetPause(rotation, now); paused {
+		log.Println("Task is paused, skipping selection for task ", taskName)
+		return recordSkip(rotation, trigger, "paused")
+	}
+
+	if len(eligibleAfterReset(task.Members, now)) < membersToSelect {
+		log.Println("Not enough eligible members to select for task ", taskName)
+		return recordSkip(rotation, trigger, "not enough eligible members")
+	}
+
+	var explanation models.SelectionExplanation
+	var servedBeforeReset []string
+	err := configs.State().UpdateTaskSelection(teamName, taskName, func(taskSelection *models.TaskSelection) error {
+		availableMembers, excluded := eligibility(task.Members, taskSelection.Members, taskSelection.Pending, now)
+		cycleReset := false
+		if len(availableMembers) < membersToSelect {
+			log.Printf("Not enough users to select for task %s. Resetting...", taskName)
+			servedBeforeReset = taskSelection.Members
+			taskSelection.Members = []string{}
+			taskSelection.Pending = nil
+			availableMembers, excluded = eligibility(task.Members, nil, nil, now)
+			availableMembers, excluded = cooldown(availableMembers, excluded, servedBeforeReset, membersToSelect)
+			cycleReset = true
+		}
+
+		pool := append([]string{}, availableMembers...)
+		seed := newSeed()
+		scores := Rank(availableMembers, now, seed)
+
+		selectedMembers := availableMembers[:membersToSelect]
+		taskSelection.Members = append(taskSelection.Members, selectedMembers...)
+
+		explanation = explain(rotation, now, selectedMembers, pool, scores, excluded, StrategyRanked, seed)
+		explanation.CycleReset = cycleReset
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error selecting users for task %s: %v", taskName, err)
+		return err
+	}
+
+	if explanation.CycleReset {
+		recordReset(rotation, trigger, servedBeforeReset)
+	}
+
+	var messageTS string
+	for _, member := range explanation.Selected {
+		log.Printf("[%s] :: %s selected user %s \n", teamName, taskName, member)
+		timestamp := configs.SendMessageToSlack(task.Message, configs.GetMemberSlackID(member), task.Channel, taskName)
+		if messageTS == "" {
+			messageTS = timestamp
+		}
+	}
+	recordSelection(models.EventSelection, explanation, trigger, messageTS)
+	return nil
+}
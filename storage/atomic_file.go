@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultBackups is the number of previous versions kept next to every state file, override it
+// with STORAGE_BACKUPS.
+const DefaultBackups = 5
+
+func backupsToKeep() int {
+	backups, err := strconv.Atoi(os.Getenv("STORAGE_BACKUPS"))
+	if err != nil || backups < 0 {
+		return DefaultBackups
+	}
+
+	return backups
+}
+
+func backupPath(path string, index int) string {
+	return path + ".bak." + strconv.Itoa(index)
+}
+
+// WriteFileAtomically replaces the file in a way that survives crashes: the data is written to a
+// temporary file in the same directory, synced and renamed over the original. The previous
+// version is kept as the newest backup first.
+func WriteFileAtomically(path string, data []byte) error {
+	if err := rotateBackups(path); err != nil {
+		log.Printf("Error backing up %s: %v", path, err)
+	}
+
+	directory := filepath.Dir(path)
+	temporaryFile, err := os.CreateTemp(directory, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	temporaryPath := temporaryFile.Name()
+
+	_, err = temporaryFile.Write(data)
+	if err == nil {
+		err = temporaryFile.Sync()
+	}
+	if closeErr := temporaryFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temporaryPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+
+	return syncDirectory(directory)
+}
+
+// rotateBackups shifts path.bak.1 .. path.bak.N-1 one position and copies the current file to
+// path.bak.1, dropping the oldest backup.
+func rotateBackups(path string) error {
+	backups := backupsToKeep()
+	if backups == 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	_ = os.Remove(backupPath(path, backups))
+	for index := backups - 1; index >= 1; index-- {
+		err := os.Rename(backupPath(path, index), backupPath(path, index+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return copyFile(path, backupPath(path, 1))
+}
+
+func copyFile(source string, destination string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(sourceFile)
+
+	destinationFile, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destinationFile, sourceFile)
+	if err == nil {
+		err = destinationFile.Sync()
+	}
+	if closeErr := destinationFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+	defer func(dir *os.File) {
+		_ = dir.Close()
+	}(dir)
+
+	// Some platforms cannot sync directories, the rename already happened so only log it
+	if err := dir.Sync(); err != nil {
+		log.Printf("Error syncing directory %s: %v", directory, err)
+	}
+
+	return nil
+}
+
+// readStateFile reads a state file, falling back to the newest backup that still parses when
+// the file is unreadable or corrupted. A missing file yields the empty value, while an error is
+// returned when the file and every backup are unreadable.
+func readStateFile[T any](path string, empty func() T) (T, error) {
+	value := empty()
+	err := readJSONFile(path, &value)
+	if err == nil {
+		return value, nil
+	}
+
+	log.Printf("!!! State file %s is unreadable: %v. Looking for a valid backup...", path, err)
+	for index := 1; index <= backupsToKeep(); index++ {
+		backup := backupPath(path, index)
+		if _, statErr := os.Stat(backup); statErr != nil {
+			continue
+		}
+
+		value := empty()
+		if backupErr := readJSONFile(backup, &value); backupErr != nil {
+			log.Printf("!!! Backup %s is unreadable too: %v", backup, backupErr)
+			continue
+		}
+
+		log.Printf("!!! Recovered state from backup %s, changes made after it was taken are lost", backup)
+		return value, nil
+	}
+
+	return empty(), fmt.Errorf("%s and all its backups are unreadable: %w", path, err)
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+	"io.mt-borring.bot/models"
+	"os"
+	"path/filepath"
+)
+
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Backend persists the bot state: current team and group selections, the append-only history,
+// configuration snapshots, member preferences, availability and paused rotations.
+type Backend interface {
+	LoadTeamSelection() (models.TeamCurrentSelection, error)
+	SaveTeamSelection(teamCurrentSelection models.TeamCurrentSelection) error
+	SaveTaskSelection(team string, task string, taskSelection models.TaskSelection) error
+
+	LoadGroupSelection() (models.GroupCurrentSelection, error)
+	SaveGroupSelection(groupCurrentSelection models.GroupCurrentSelection) error
+	SaveSupportSelection(group string, storedSupportDefinition models.StoredSupportDefinition) error
+
+	AppendHistory(event models.HistoryEvent) error
+	ReadHistory() ([]models.HistoryEvent, error)
+
+	AppendConfigSnapshot(snapshot models.ConfigSnapshot) error
+	ReadConfigSnapshots() ([]models.ConfigSnapshot, error)
+
+	LoadMemberPreferences() (models.MemberPreferencesStorage, error)
+	SaveMemberPreferences(memberPreferences models.MemberPreferencesStorage) error
+
+	LoadAvailability() (models.AvailabilityStorage, error)
+	SaveAvailability(availability models.AvailabilityStorage) error
+
+	LoadPauses() (models.PauseStorage, error)
+	SavePauses(pauses models.PauseStorage) error
+
+	Close() error
+}
+
+// NewBackendFromEnv opens the backend selected by STORAGE_BACKEND, "json" (default) or "sqlite",
+// keeping its files in DATA_DIR (default the working directory). The SQLite database file can be
+// moved elsewhere with SQLITE_PATH.
+func NewBackendFromEnv() (Backend, error) {
+	directory := dataDirectory()
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	switch os.Getenv("STORAGE_BACKEND") {
+	case "", BackendJSON:
+		jsonBackend, err := NewJSONBackend(directory)
+		if err != nil {
+			return nil, err
+		}
+		return jsonBackend, nil
+	case BackendSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = filepath.Join(directory, "mr-boring.db")
+		}
+		return NewSQLiteBackend(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", os.Getenv("STORAGE_BACKEND"))
+	}
+}
+
+// dataDirectory returns the directory the state is kept in, taken from DATA_DIR.
+func dataDirectory() string {
+	if directory := os.Getenv("DATA_DIR"); directory != "" {
+		return directory
+	}
+
+	return "."
+}
+
+func emptyTeamSelection() models.TeamCurrentSelection {
+	return models.TeamCurrentSelection{Teams: make(map[string]map[string]models.TaskSelection)}
+}
+
+func emptyGroupSelection() models.GroupCurrentSelection {
+	return models.GroupCurrentSelection{Groups: make(map[string]models.StoredSupportDefinition)}
+}
+
+func emptyMemberPreferences() models.MemberPreferencesStorage {
+	return models.MemberPreferencesStorage{Members: make(map[string]models.MemberPreferences)}
+}
+
+func emptyAvailability() models.AvailabilityStorage {
+	return models.AvailabilityStorage{Members: make(map[string][]models.AwayPeriod)}
+}
+
+func emptyPauses() models.PauseStorage {
+	return models.PauseStorage{Pauses: []models.Pause{}}
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"io.mt-borring.bot/models"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONBackend keeps the state in JSON files inside the data directory, the same files the bot has
+// always used.
+type JSONBackend struct {
+	mu                 sync.Mutex
+	teamSelectionFile  string
+	groupSelectionFile string
+	historyFile        string
+	snapshotsFile      string
+	preferencesFile    string
+	availabilityFile   string
+	pausesFile         string
+}
+
+// NewJSONBackend keeps the state in the given directory, migrating the files written by older
+// versions of the bot first.
+func NewJSONBackend(directory string) (*JSONBackend, error) {
+	backend := newJSONBackend(directory)
+
+	for _, path := range []string{backend.teamSelectionFile, backend.groupSelectionFile, backend.preferencesFile, backend.availabilityFile, backend.pausesFile} {
+		if err := migrateStateFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := migrateHistoryFile(backend.historyFile); err != nil {
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+func newJSONBackend(directory string) *JSONBackend {
+	return &JSONBackend{
+		teamSelectionFile:  filepath.Join(directory, "current_selection_storage.json"),
+		groupSelectionFile: filepath.Join(directory, "current_support_selection_storage.json"),
+		historyFile:        filepath.Join(directory, "selection_history.jsonl"),
+		snapshotsFile:      filepath.Join(directory, "config_snapshots.jsonl"),
+		preferencesFile:    filepath.Join(directory, "member_preferences_storage.json"),
+		availabilityFile:   filepath.Join(directory, "availability_storage.json"),
+		pausesFile:         filepath.Join(directory, "pauses_storage.json"),
+	}
+}
+
+func (backend *JSONBackend) LoadTeamSelection() (models.TeamCurrentSelection, error) {
+	teamCurrentSelection, err := readStateFile(backend.teamSelectionFile, emptyTeamSelection)
+	if teamCurrentSelection.Teams == nil {
+		teamCurrentSelection = emptyTeamSelection()
+	}
+
+	return teamCurrentSelection, err
+}
+
+func (backend *JSONBackend) SaveTeamSelection(teamCurrentSelection models.TeamCurrentSelection) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return writeJSONFile(backend.teamSelectionFile, teamCurrentSelection)
+}
+
+func (backend *JSONBackend) SaveTaskSelection(team string, task string, taskSelection models.TaskSelection) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	teamCurrentSelection, err := backend.LoadTeamSelection()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := teamCurrentSelection.Teams[team]; !ok {
+		teamCurrentSelection.Teams[team] = make(map[string]models.TaskSelection)
+	}
+	teamCurrentSelection.Teams[team][task] = taskSelection
+
+	return writeJSONFile(backend.teamSelectionFile, teamCurrentSelection)
+}
+
+func (backend *JSONBackend) LoadGroupSelection() (models.GroupCurrentSelection, error) {
+	groupCurrentSelection, err := readStateFile(backend.groupSelectionFile, emptyGroupSelection)
+	if groupCurrentSelection.Groups == nil {
+		groupCurrentSelection = emptyGroupSelection()
+	}
+
+	return groupCurrentSelection, err
+}
+
+func (backend *JSONBackend) SaveGroupSelection(groupCurrentSelection models.GroupCurrentSelection) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return writeJSONFile(backend.groupSelectionFile, groupCurrentSelection)
+}
+
+func (backend *JSONBackend) SaveSupportSelection(group string, storedSupportDefinition models.StoredSupportDefinition) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	groupCurrentSelection, err := backend.LoadGroupSelection()
+	if err != nil {
+		return err
+	}
+	groupCurrentSelection.Groups[group] = storedSupportDefinition
+
+	return writeJSONFile(backend.groupSelectionFile, groupCurrentSelection)
+}
+
+func (backend *JSONBackend) AppendHistory(event models.HistoryEvent) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return appendJSONLine(backend.historyFile, event)
+}
+
+func (backend *JSONBackend) ReadHistory() ([]models.HistoryEvent, error) {
+	return readJSONLines[models.HistoryEvent](backend.historyFile, documentHistory)
+}
+
+func (backend *JSONBackend) AppendConfigSnapshot(snapshot models.ConfigSnapshot) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return appendJSONLine(backend.snapshotsFile, snapshot)
+}
+
+func (backend *JSONBackend) ReadConfigSnapshots() ([]models.ConfigSnapshot, error) {
+	return readJSONLines[models.ConfigSnapshot](backend.snapshotsFile, documentState)
+}
+
+func (backend *JSONBackend) LoadMemberPreferences() (models.MemberPreferencesStorage, error) {
+	memberPreferences, err := readStateFile(backend.preferencesFile, emptyMemberPreferences)
+	if memberPreferences.Members == nil {
+		memberPreferences = emptyMemberPreferences()
+	}
+
+	return memberPreferences, err
+}
+
+func (backend *JSONBackend) SaveMemberPreferences(memberPreferences models.MemberPreferencesStorage) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return writeJSONFile(backend.preferencesFile, memberPreferences)
+}
+
+func (backend *JSONBackend) LoadAvailability() (models.AvailabilityStorage, error) {
+	availability, err := readStateFile(backend.availabilityFile, emptyAvailability)
+	if availability.Members == nil {
+		availability = emptyAvailability()
+	}
+
+	return availability, err
+}
+
+func (backend *JSONBackend) SaveAvailability(availability models.AvailabilityStorage) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return writeJSONFile(backend.availabilityFile, availability)
+}
+
+func (backend *JSONBackend) LoadPauses() (models.PauseStorage, error) {
+	pauses, err := readStateFile(backend.pausesFile, emptyPauses)
+	if pauses.Pauses == nil {
+		pauses = emptyPauses()
+	}
+
+	return pauses, err
+}
+
+func (backend *JSONBackend) SavePauses(pauses models.PauseStorage) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	return writeJSONFile(backend.pausesFile, pauses)
+}
+
+func (backend *JSONBackend) Close() error {
+	return nil
+}
+
+// appendJSONLine appends a document to a JSONL file and syncs it.
+func appendJSONLine(path string, value any) error {
+	data, err := encodeDocument(value, false)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			log.Println("Error closing", path, err)
+		}
+	}(file)
+
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	return err
+}
+
+// maxJSONLineSize bounds the size of a single entry of the JSONL files, when reading and migrating them.
+const maxJSONLineSize = 16 * 1024 * 1024
+
+// readJSONLines reads every document of a JSONL file, skipping the lines that do not parse.
+func readJSONLines[T any](path string, kind string) ([]T, error) {
+	values := []T{}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return values, err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			log.Println("Error closing", path, err)
+		}
+	}(file)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLineSize)
+	for scanner.Scan() {
+		var value T
+		if err := decodeDocument(kind, scanner.Bytes(), &value); err != nil {
+			log.Println("Error parsing entry of", path, err)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	return values, scanner.Err()
+}
+
+// readJSONFile leaves the value untouched when the file does not exist yet.
+func readJSONFile(path string, value any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return decodeDocument(documentState, data, value)
+}
+
+func writeJSONFile(path string, value any) error {
+	data, err := encodeDocument(value, true)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomically(path, data)
+}
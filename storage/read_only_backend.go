@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"os"
+	"path/filepath"
+)
+
+var ErrReadOnly = errors.New("the storage is opened read-only")
+
+// NewReadOnlyBackendFromEnv opens the backend selected by STORAGE_BACKEND like NewBackendFromEnv,
+// without migrating or writing anything, for the commands that only inspect the state. JSON files
+// of older schema versions are migrated in memory as they are read, SQLite databases must be at the
+// current schema version.
+func NewReadOnlyBackendFromEnv() (Backend, error) {
+	directory := dataDirectory()
+
+	switch os.Getenv("STORAGE_BACKEND") {
+	case "", BackendJSON:
+		return readOnlyBackend{newJSONBackend(directory)}, nil
+	case BackendSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = filepath.Join(directory, "mr-boring.db")
+		}
+		sqliteBackend, err := newReadOnlySQLiteBackend(path)
+		if err != nil {
+			return nil, err
+		}
+		return readOnlyBackend{sqliteBackend}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", os.Getenv("STORAGE_BACKEND"))
+	}
+}
+
+func newReadOnlySQLiteBackend(path string) (*SQLiteBackend, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	var current int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err == nil && current != len(sqliteMigrations) {
+		err = fmt.Errorf("%s is at schema version %d instead of %d, start the bot once to migrate it", path, current, len(sqliteMigrations))
+	}
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &SQLiteBackend{db: db}, nil
+}
+
+// readOnlyBackend rejects every write, so inspecting the state never changes it.
+type readOnlyBackend struct {
+	Backend
+}
+
+func (readOnlyBackend) SaveTeamSelection(models.TeamCurrentSelection) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SaveTaskSelection(string, string, models.TaskSelection) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SaveGroupSelection(models.GroupCurrentSelection) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SaveSupportSelection(string, models.StoredSupportDefinition) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) AppendHistory(models.HistoryEvent) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) AppendConfigSnapshot(models.ConfigSnapshot) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SaveMemberPreferences(models.MemberPreferencesStorage) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SaveAvailability(models.AvailabilityStorage) error {
+	return ErrReadOnly
+}
+
+func (readOnlyBackend) SavePauses(models.PauseStorage) error {
+	return ErrReadOnly
+}
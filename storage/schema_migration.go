@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"log"
+	"maps"
+	"os"
+	"strconv"
+)
+
+const (
+	documentState   = "state"
+	documentHistory = "history"
+)
+
+type schemaMigration struct {
+	description string
+	migrate     func(kind string, document map[string]any) error
+}
+
+// schemaMigrations upgrade the JSON state documents, schemaMigrations[i] takes a document from
+// version i to version i+1. Documents written before versioning existed are version 0. Never
+// change a released migration, append a new one instead.
+var schemaMigrations = []schemaMigration{
+	{
+		description: "history entries holding only a selection explanation become events",
+		migrate:     migrateExplanationToEvent,
+	},
+}
+
+// SchemaVersion is the version of the documents written by this build, recorded as schemaVersion
+// in every JSON state file and history entry.
+func SchemaVersion() int {
+	return len(schemaMigrations)
+}
+
+func migrationBackupPath(path string, version int) string {
+	return path + ".bak.schema-v" + strconv.Itoa(version)
+}
+
+// migrateDocument upgrades a document to the current schema version and returns the version it
+// had before.
+func migrateDocument(kind string, document map[string]any) (int, error) {
+	version, err := documentVersion(document)
+	if err != nil {
+		return version, err
+	}
+
+	if err := migrateFrom(kind, document, version); err != nil {
+		return version, err
+	}
+	document["schemaVersion"] = SchemaVersion()
+
+	return version, nil
+}
+
+// documentVersion returns the schemaVersion recorded in a document, 0 when there is none.
+func documentVersion(document map[string]any) (int, error) {
+	version := 0
+	if recorded, ok := document["schemaVersion"].(json.Number); ok {
+		parsed, err := strconv.Atoi(recorded.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid schemaVersion %s", recorded)
+		}
+		version = parsed
+	}
+
+	if version > SchemaVersion() {
+		return version, fmt.Errorf("schema version %d is newer than version %d supported by this build", version, SchemaVersion())
+	}
+
+	return version, nil
+}
+
+// migrateFrom applies the migrations that follow the given version to a document.
+func migrateFrom(kind string, document map[string]any, version int) error {
+	for current := version; current < SchemaVersion(); current++ {
+		if err := schemaMigrations[current].migrate(kind, document); err != nil {
+			return fmt.Errorf("migrating to schema version %d (%s): %w", current+1, schemaMigrations[current].description, err)
+		}
+	}
+
+	return nil
+}
+
+// DecodeStateDocument decodes an exported state document, migrating it first when it was exported
+// by an older schema version. Unknown fields are rejected.
+func DecodeStateDocument(data []byte) (models.StateDocument, error) {
+	var stateDocument models.StateDocument
+
+	document, err := parseDocument(data)
+	if err != nil {
+		return stateDocument, err
+	}
+
+	version, err := documentVersion(document)
+	if err != nil {
+		return stateDocument, err
+	}
+
+	for _, part := range []string{"teamSelection", "groupSelection", "memberPreferences", "availability", "pauses"} {
+		if value, ok := document[part].(map[string]any); ok {
+			if err := migrateFrom(documentState, value, version); err != nil {
+				return stateDocument, fmt.Errorf("%s: %w", part, err)
+			}
+		}
+	}
+
+	if entries, ok := document["history"].([]any); ok {
+		for i, entry := range entries {
+			if value, ok := entry.(map[string]any); ok {
+				if err := migrateFrom(documentHistory, value, version); err != nil {
+					return stateDocument, fmt.Errorf("history[%d]: %w", i, err)
+				}
+			}
+		}
+	}
+	document["schemaVersion"] = SchemaVersion()
+
+	migrated, err := json.Marshal(document)
+	if err != nil {
+		return stateDocument, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(migrated))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&stateDocument)
+	return stateDocument, err
+}
+
+func parseDocument(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as they are, selection seeds do not fit in a float64
+	decoder.UseNumber()
+
+	var document map[string]any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		document = make(map[string]any)
+	}
+
+	return document, nil
+}
+
+// decodeDocument migrates a JSON state document to the current schema and decodes it into value.
+func decodeDocument(kind string, data []byte, value any) error {
+	document, err := parseDocument(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := migrateDocument(kind, document); err != nil {
+		return err
+	}
+
+	migrated, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(migrated, value)
+}
+
+// encodeDocument marshals a state value recording the current schema version.
+func encodeDocument(value any, indent bool) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	document["schemaVersion"] = json.RawMessage(strconv.Itoa(SchemaVersion()))
+
+	if indent {
+		return json.MarshalIndent(document, "", "  ")
+	}
+	return json.Marshal(document)
+}
+
+// migrateStateFile rewrites a JSON state file written by an older schema version, keeping the
+// original as path.bak.schema-v<version>. Unreadable files are left to the backup recovery.
+func migrateStateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	document, err := parseDocument(data)
+	if err != nil {
+		log.Printf("Skipping the migration of %s: %v", path, err)
+		return nil
+	}
+
+	version, err := migrateDocument(documentState, document)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if version == SchemaVersion() {
+		return nil
+	}
+
+	migrated, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return replaceMigratedFile(path, version, migrated)
+}
+
+// migrateHistoryFile rewrites the history log when any of its entries was written by an older
+// schema version, keeping the original as path.bak.schema-v<version>.
+func migrateHistoryFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var migrated bytes.Buffer
+	oldestVersion := SchemaVersion()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		document, err := parseDocument(line)
+		if err != nil {
+			// Keep unreadable entries as they are, reading the history skips them
+			migrated.Write(line)
+			migrated.WriteByte('\n')
+			continue
+		}
+
+		version, err := migrateDocument(documentHistory, document)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		oldestVersion = min(oldestVersion, version)
+
+		entry, err := json.Marshal(document)
+		if err != nil {
+			return err
+		}
+		migrated.Write(entry)
+		migrated.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if oldestVersion == SchemaVersion() {
+		return nil
+	}
+
+	return replaceMigratedFile(path, oldestVersion, migrated.Bytes())
+}
+
+func replaceMigratedFile(path string, version int, migrated []byte) error {
+	backup := migrationBackupPath(path, version)
+	if err := copyFile(path, backup); err != nil {
+		return fmt.Errorf("backing up %s before migrating: %w", path, err)
+	}
+
+	if err := WriteFileAtomically(path, migrated); err != nil {
+		return err
+	}
+
+	log.Printf("Migrated %s from schema version %d to %d, the original is kept as %s", path, version, SchemaVersion(), backup)
+	return nil
+}
+
+// migrateExplanationToEvent wraps the history entries written before events existed, which only
+// held the explanation of a selection, in a selection or replacement event.
+func migrateExplanationToEvent(kind string, document map[string]any) error {
+	if kind != documentHistory {
+		return nil
+	}
+	if _, ok := document["type"]; ok {
+		return nil
+	}
+
+	explanation := maps.Clone(document)
+	delete(explanation, "schemaVersion")
+	clear(document)
+
+	document["type"] = models.EventSelection
+	if replaced, _ := explanation["replaced"].(string); replaced != "" {
+		document["type"] = models.EventReplacement
+	}
+	document["timestamp"] = explanation["timestamp"]
+	document["rotation"] = explanation["rotation"]
+	document["members"] = explanation["selected"]
+	document["trigger"] = models.TriggerCron
+	document["explanation"] = explanation
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"encoding/json"
+	"io.mt-borring.bot/models"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// copyFixture copies a file of testdata into a temporary directory, under the given name.
+func copyFixture(t *testing.T, fixture string, name string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := copyFile(filepath.Join("testdata", fixture), path); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestMigrateStateFileFromVersion0(t *testing.T) {
+	path := copyFixture(t, "current_selection_storage.v0.json", "current_selection_storage.json")
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateStateFile(path); err != nil {
+		t.Fatalf("migrateStateFile: %v", err)
+	}
+
+	backup, err := os.ReadFile(migrationBackupPath(path, 0))
+	if err != nil {
+		t.Fatalf("the original was not backed up: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("backup differs from the original")
+	}
+
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := parseDocument(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document["schemaVersion"] != json.Number("1") {
+		t.Errorf("schemaVersion %v, want 1", document["schemaVersion"])
+	}
+
+	var teamCurrentSelection models.TeamCurrentSelection
+	if err := decodeDocument(documentState, migrated, &teamCurrentSelection); err != nil {
+		t.Fatal(err)
+	}
+	support := teamCurrentSelection.Teams["payments-zeus"]["support"]
+	if !slices.Equal(support.Members, []string{"VelvetShadow", "PixelDreamer"}) || !slices.Equal(support.Pending, []string{"LunarEcho"}) {
+		t.Errorf("selection changed by the migration: %+v", support)
+	}
+
+	if err := migrateStateFile(path); err != nil {
+		t.Fatalf("migrating again: %v", err)
+	}
+	if _, err := os.Stat(migrationBackupPath(path, 1)); !os.IsNotExist(err) {
+		t.Errorf("a current file was migrated again")
+	}
+}
+
+func TestMigrateHistoryFileFromVersion0(t *testing.T) {
+	path := copyFixture(t, "selection_history.v0.jsonl", "selection_history.jsonl")
+
+	if err := migrateHistoryFile(path); err != nil {
+		t.Fatalf("migrateHistoryFile: %v", err)
+	}
+	if _, err := os.Stat(migrationBackupPath(path, 0)); err != nil {
+		t.Errorf("the original was not backed up: %v", err)
+	}
+
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(migrated), "not a json entry\n") {
+		t.Errorf("the unreadable entry was not kept as it was")
+	}
+
+	events, err := readJSONLines[models.HistoryEvent](path, documentHistory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+
+	selection, replacement := events[0], events[1]
+	if selection.Type != models.EventSelection || !slices.Equal(selection.Members, []string{"Maria"}) || selection.Trigger != models.TriggerCron {
+		t.Errorf("selection migrated to %+v", selection)
+	}
+	if selection.Explanation == nil || selection.Explanation.Seed != 8446744073709551615 {
+		t.Errorf("the explanation or its seed was not kept: %+v", selection.Explanation)
+	}
+	if replacement.Type != models.EventReplacement || replacement.Explanation == nil || replacement.Explanation.Replaced != "Maria" {
+		t.Errorf("replacement migrated to %+v", replacement)
+	}
+}
+
+func TestMigrateDocumentRejectsNewerVersions(t *testing.T) {
+	document := map[string]any{"schemaVersion": json.Number("99")}
+	if _, err := migrateDocument(documentState, document); err == nil {
+		t.Error("a document of a newer schema version was accepted")
+	}
+}
+
+func TestDecodeStateDocumentFromVersion0(t *testing.T) {
+	data := []byte(`{
+		"exportedAt": "2025-03-03T11:00:00Z",
+		"teamSelection": {"teams": {"payments-zeus": {"daily": {"members": ["Maria"]}}}},
+		"history": [{"timestamp":"2025-03-03T10:00:00Z","rotation":{"type":"teams","name":"payments-zeus","task":"daily"},"selected":["Ana"],"replaced":"Maria","strategy":"replacement","seed":7}]
+	}`)
+
+	document, err := DecodeStateDocument(data)
+	if err != nil {
+		t.Fatalf("DecodeStateDocument: %v", err)
+	}
+	if document.SchemaVersion != SchemaVersion() {
+		t.Errorf("schemaVersion %d, want %d", document.SchemaVersion, SchemaVersion())
+	}
+	if members := document.TeamSelection.Teams["payments-zeus"]["daily"].Members; !slices.Equal(members, []string{"Maria"}) {
+		t.Errorf("selection changed by the migration: %v", members)
+	}
+	if len(document.History) != 1 || document.History[0].Type != models.EventReplacement || document.History[0].Explanation == nil {
+		t.Errorf("history migrated to %+v", document.History)
+	}
+
+	if _, err := DecodeStateDocument([]byte(`{"schemaVersion": 99}`)); err == nil {
+		t.Error("a document of a newer schema version was accepted")
+	}
+}
@@ -0,0 +1,480 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io.mt-borring.bot/models"
+	"log"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are applied in order, each one exactly once. Never change a released
+// migration, append a new one instead.
+var sqliteMigrations = []string{
+	`CREATE TABLE team_selections (
+		team TEXT NOT NULL,
+		task TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (team, task)
+	);
+	CREATE TABLE group_selections (
+		group_name TEXT NOT NULL PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE selection_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		recorded_at TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE member_preferences (
+		member TEXT NOT NULL PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE availability (
+		member TEXT NOT NULL PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE pauses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		data TEXT NOT NULL
+	);`,
+	// History entries written before events existed only held the explanation of a selection
+	`UPDATE selection_history SET data = json_object(
+		'timestamp', json_extract(data, '$.timestamp'),
+		'type', CASE WHEN COALESCE(json_extract(data, '$.replaced'), '') = '' THEN 'selection' ELSE 'replacement' END,
+		'rotation', json(json_extract(data, '$.rotation')),
+		'members', json(COALESCE(json_extract(data, '$.selected'), '[]')),
+		'trigger', 'cron',
+		'explanation', json(data)
+	) WHERE json_extract(data, '$.type') IS NULL;`,
+	`CREATE TABLE config_snapshots (
+		version INTEGER NOT NULL PRIMARY KEY,
+		data TEXT NOT NULL
+	);`,
+}
+
+// SQLiteBackend keeps the state in a SQLite database, one row per rotation so a selection only
+// writes the rows it changed.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+func NewSQLiteBackend(path string) (*SQLiteBackend, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows one writer, serialise everything through a single connection
+	db.SetMaxOpenConns(1)
+
+	backend := &SQLiteBackend{db: db}
+	if err := backend.migrate(path); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+// migrate applies the pending migrations, copying the database to path.bak.schema-v<version>
+// first when it already holds data.
+func (backend *SQLiteBackend) migrate(path string) error {
+	_, err := backend.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = backend.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	if current > 0 && current < len(sqliteMigrations) {
+		backup := migrationBackupPath(path, current)
+		_ = os.Remove(backup)
+		if _, err := backend.db.Exec(`VACUUM INTO ?`, backup); err != nil {
+			return fmt.Errorf("backing up %s before migrating: %w", path, err)
+		}
+		log.Printf("Backed up %s to %s before migrating", path, backup)
+	}
+
+	for version := current + 1; version <= len(sqliteMigrations); version++ {
+		err := backend.inTransaction(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(sqliteMigrations[version-1]); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC().Format(time.RFC3339))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("applying migration %d: %w", version, err)
+		}
+
+		log.Println("Applied SQLite migration", version)
+	}
+
+	return nil
+}
+
+func (backend *SQLiteBackend) inTransaction(apply func(tx *sql.Tx) error) error {
+	tx, err := backend.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := apply(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (backend *SQLiteBackend) LoadTeamSelection() (models.TeamCurrentSelection, error) {
+	teamCurrentSelection := emptyTeamSelection()
+	rows, err := backend.db.Query(`SELECT team, task, data FROM team_selections`)
+	if err != nil {
+		return teamCurrentSelection, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var team, task, data string
+		if err := rows.Scan(&team, &task, &data); err != nil {
+			return emptyTeamSelection(), err
+		}
+
+		var taskSelection models.TaskSelection
+		if err := json.Unmarshal([]byte(data), &taskSelection); err != nil {
+			return emptyTeamSelection(), err
+		}
+
+		if _, ok := teamCurrentSelection.Teams[team]; !ok {
+			teamCurrentSelection.Teams[team] = make(map[string]models.TaskSelection)
+		}
+		teamCurrentSelection.Teams[team][task] = taskSelection
+	}
+
+	return teamCurrentSelection, rows.Err()
+}
+
+func (backend *SQLiteBackend) SaveTeamSelection(teamCurrentSelection models.TeamCurrentSelection) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM team_selections`); err != nil {
+			return err
+		}
+
+		for team, tasks := range teamCurrentSelection.Teams {
+			for task, taskSelection := range tasks {
+				if err := upsertTaskSelection(tx, team, task, taskSelection); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (backend *SQLiteBackend) SaveTaskSelection(team string, task string, taskSelection models.TaskSelection) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		return upsertTaskSelection(tx, team, task, taskSelection)
+	})
+}
+
+func upsertTaskSelection(tx *sql.Tx, team string, task string, taskSelection models.TaskSelection) error {
+	data, err := json.Marshal(taskSelection)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO team_selections (team, task, data) VALUES (?, ?, ?)
+		ON CONFLICT (team, task) DO UPDATE SET data = excluded.data`, team, task, string(data))
+	return err
+}
+
+func (backend *SQLiteBackend) LoadGroupSelection() (models.GroupCurrentSelection, error) {
+	groupCurrentSelection := emptyGroupSelection()
+	rows, err := backend.db.Query(`SELECT group_name, data FROM group_selections`)
+	if err != nil {
+		return groupCurrentSelection, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var group, data string
+		if err := rows.Scan(&group, &data); err != nil {
+			return emptyGroupSelection(), err
+		}
+
+		var storedSupportDefinition models.StoredSupportDefinition
+		if err := json.Unmarshal([]byte(data), &storedSupportDefinition); err != nil {
+			return emptyGroupSelection(), err
+		}
+		groupCurrentSelection.Groups[group] = storedSupportDefinition
+	}
+
+	return groupCurrentSelection, rows.Err()
+}
+
+func (backend *SQLiteBackend) SaveGroupSelection(groupCurrentSelection models.GroupCurrentSelection) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM group_selections`); err != nil {
+			return err
+		}
+
+		for group, storedSupportDefinition := range groupCurrentSelection.Groups {
+			if err := upsertSupportSelection(tx, group, storedSupportDefinition); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (backend *SQLiteBackend) SaveSupportSelection(group string, storedSupportDefinition models.StoredSupportDefinition) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		return upsertSupportSelection(tx, group, storedSupportDefinition)
+	})
+}
+
+func upsertSupportSelection(tx *sql.Tx, group string, storedSupportDefinition models.StoredSupportDefinition) error {
+	data, err := json.Marshal(storedSupportDefinition)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO group_selections (group_name, data) VALUES (?, ?)
+		ON CONFLICT (group_name) DO UPDATE SET data = excluded.data`, group, string(data))
+	return err
+}
+
+func (backend *SQLiteBackend) AppendHistory(event models.HistoryEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.db.Exec(`INSERT INTO selection_history (recorded_at, data) VALUES (?, ?)`,
+		event.Timestamp.UTC().Format(time.RFC3339Nano), string(data))
+	return err
+}
+
+func (backend *SQLiteBackend) ReadHistory() ([]models.HistoryEvent, error) {
+	history := []models.HistoryEvent{}
+	rows, err := backend.db.Query(`SELECT data FROM selection_history ORDER BY id`)
+	if err != nil {
+		return history, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return history, err
+		}
+
+		var event models.HistoryEvent
+		err := json.Unmarshal([]byte(data), &event)
+		if err != nil {
+			log.Println("Error parsing history entry:", err)
+			continue
+		}
+		history = append(history, event)
+	}
+
+	return history, rows.Err()
+}
+
+func (backend *SQLiteBackend) AppendConfigSnapshot(snapshot models.ConfigSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.db.Exec(`INSERT INTO config_snapshots (version, data) VALUES (?, ?)`, snapshot.Version, string(data))
+	return err
+}
+
+func (backend *SQLiteBackend) ReadConfigSnapshots() ([]models.ConfigSnapshot, error) {
+	snapshots := []models.ConfigSnapshot{}
+	rows, err := backend.db.Query(`SELECT data FROM config_snapshots ORDER BY version`)
+	if err != nil {
+		return snapshots, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return snapshots, err
+		}
+
+		var snapshot models.ConfigSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			log.Println("Error parsing config snapshot:", err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (backend *SQLiteBackend) LoadMemberPreferences() (models.MemberPreferencesStorage, error) {
+	memberPreferences := emptyMemberPreferences()
+	err := backend.loadMemberRows(`SELECT member, data FROM member_preferences`, func(member string, data []byte) error {
+		var preferences models.MemberPreferences
+		if err := json.Unmarshal(data, &preferences); err != nil {
+			return err
+		}
+		memberPreferences.Members[member] = preferences
+		return nil
+	})
+	if err != nil {
+		return emptyMemberPreferences(), err
+	}
+
+	return memberPreferences, nil
+}
+
+func (backend *SQLiteBackend) SaveMemberPreferences(memberPreferences models.MemberPreferencesStorage) error {
+	rows := make(map[string]any, len(memberPreferences.Members))
+	for member, preferences := range memberPreferences.Members {
+		rows[member] = preferences
+	}
+
+	return backend.replaceMemberRows("member_preferences", rows)
+}
+
+func (backend *SQLiteBackend) LoadAvailability() (models.AvailabilityStorage, error) {
+	availability := emptyAvailability()
+	err := backend.loadMemberRows(`SELECT member, data FROM availability`, func(member string, data []byte) error {
+		var periods []models.AwayPeriod
+		if err := json.Unmarshal(data, &periods); err != nil {
+			return err
+		}
+		availability.Members[member] = periods
+		return nil
+	})
+	if err != nil {
+		return emptyAvailability(), err
+	}
+
+	return availability, nil
+}
+
+func (backend *SQLiteBackend) SaveAvailability(availability models.AvailabilityStorage) error {
+	rows := make(map[string]any, len(availability.Members))
+	for member, periods := range availability.Members {
+		rows[member] = periods
+	}
+
+	return backend.replaceMemberRows("availability", rows)
+}
+
+func (backend *SQLiteBackend) LoadPauses() (models.PauseStorage, error) {
+	pauses := emptyPauses()
+	rows, err := backend.db.Query(`SELECT data FROM pauses ORDER BY id`)
+	if err != nil {
+		return pauses, err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return emptyPauses(), err
+		}
+
+		var pause models.Pause
+		if err := json.Unmarshal([]byte(data), &pause); err != nil {
+			return emptyPauses(), err
+		}
+		pauses.Pauses = append(pauses.Pauses, pause)
+	}
+
+	return pauses, rows.Err()
+}
+
+func (backend *SQLiteBackend) SavePauses(pauses models.PauseStorage) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM pauses`); err != nil {
+			return err
+		}
+
+		for _, pause := range pauses.Pauses {
+			data, err := json.Marshal(pause)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(`INSERT INTO pauses (data) VALUES (?)`, string(data)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (backend *SQLiteBackend) Close() error {
+	return backend.db.Close()
+}
+
+func (backend *SQLiteBackend) loadMemberRows(query string, apply func(member string, data []byte) error) error {
+	rows, err := backend.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows)
+
+	for rows.Next() {
+		var member, data string
+		if err := rows.Scan(&member, &data); err != nil {
+			return err
+		}
+
+		if err := apply(member, []byte(data)); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// replaceMemberRows rewrites a member keyed table, the table name is never user input.
+func (backend *SQLiteBackend) replaceMemberRows(table string, rows map[string]any) error {
+	return backend.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return err
+		}
+
+		for member, value := range rows {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(`INSERT INTO `+table+` (member, data) VALUES (?, ?)`, member, string(data)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		log.Println("Error closing rows:", err)
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"database/sql"
+	"io.mt-borring.bot/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSQLiteMigrations opens a database left at every schema version with the data of that version,
+// and checks the migrations that follow bring it to the current schema.
+func TestSQLiteMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		fixture string
+		check   func(t *testing.T, backend *SQLiteBackend)
+	}{
+		{
+			name:    "empty database",
+			version: 0,
+			check: func(t *testing.T, backend *SQLiteBackend) {
+				if _, err := backend.LoadTeamSelection(); err != nil {
+					t.Errorf("tables missing: %v", err)
+				}
+			},
+		},
+		{
+			name:    "history entries before events",
+			version: 1,
+			fixture: `INSERT INTO selection_history (recorded_at, data) VALUES
+				('2025-03-03T09:00:00Z', '{"timestamp":"2025-03-03T09:00:00Z","rotation":{"type":"teams","name":"payments-zeus","task":"daily"},"selected":["Maria"],"strategy":"ranked","seed":42}'),
+				('2025-03-03T10:00:00Z', '{"timestamp":"2025-03-03T10:00:00Z","rotation":{"type":"teams","name":"payments-zeus","task":"daily"},"selected":["Ana"],"replaced":"Maria","strategy":"replacement","seed":7}');`,
+			check: func(t *testing.T, backend *SQLiteBackend) {
+				events, err := backend.ReadHistory()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(events) != 2 {
+					t.Fatalf("%d events, want 2", len(events))
+				}
+				if events[0].Type != models.EventSelection || len(events[0].Members) != 1 || events[0].Members[0] != "Maria" || events[0].Explanation == nil {
+					t.Errorf("selection migrated to %+v", events[0])
+				}
+				if events[1].Type != models.EventReplacement || events[1].Explanation == nil || events[1].Explanation.Replaced != "Maria" {
+					t.Errorf("replacement migrated to %+v", events[1])
+				}
+			},
+		},
+		{
+			name:    "before configuration snapshots",
+			version: 2,
+			fixture: `INSERT INTO team_selections (team, task, data) VALUES ('payments-zeus', 'daily', '{"members":["Maria"]}');`,
+			check: func(t *testing.T, backend *SQLiteBackend) {
+				if err := backend.AppendConfigSnapshot(models.ConfigSnapshot{Version: 1, Author: "system", Source: models.SnapshotLoad}); err != nil {
+					t.Fatalf("config_snapshots missing: %v", err)
+				}
+				teamCurrentSelection, err := backend.LoadTeamSelection()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if members := teamCurrentSelection.Teams["payments-zeus"]["daily"].Members; len(members) != 1 || members[0] != "Maria" {
+					t.Errorf("selection changed by the migration: %v", members)
+				}
+			},
+		},
+	}
+
+	if len(tests) != len(sqliteMigrations) {
+		t.Fatalf("%d migration fixtures for %d migrations, add one for the new migration", len(tests), len(sqliteMigrations))
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mr-boring.db")
+			seedSQLiteDatabase(t, path, test.version, test.fixture)
+
+			backend, err := NewSQLiteBackend(path)
+			if err != nil {
+				t.Fatalf("migrating from version %d: %v", test.version, err)
+			}
+			defer func() { _ = backend.Close() }()
+
+			var current int
+			if err := backend.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&current); err != nil {
+				t.Fatal(err)
+			}
+			if current != len(sqliteMigrations) {
+				t.Errorf("schema version %d, want %d", current, len(sqliteMigrations))
+			}
+
+			_, err = os.Stat(migrationBackupPath(path, test.version))
+			if test.version > 0 && err != nil {
+				t.Errorf("the database was not backed up before migrating: %v", err)
+			}
+
+			test.check(t, backend)
+		})
+	}
+}
+
+// seedSQLiteDatabase creates a database at the given schema version holding the fixture.
+func seedSQLiteDatabase(t *testing.T, path string, version int, fixture string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	for applied := 1; applied <= version; applied++ {
+		if _, err := db.Exec(sqliteMigrations[applied-1]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, '2025-01-01T00:00:00Z')`, applied); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fixture != "" {
+		if _, err := db.Exec(fixture); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -2,16 +2,21 @@ package utils
 
 import (
 	"math/rand"
-	"time"
+	"sort"
+	"strings"
 )
 
-func Shuffle(slice []string) {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	rand.Shuffle(len(slice), func(i, j int) {
+func Shuffle(slice []string, random *rand.Rand) {
+	random.Shuffle(len(slice), func(i, j int) {
 		slice[i], slice[j] = slice[j], slice[i]
 	})
 }
 
+// ShuffleWithSeed shuffles the slice in a reproducible way, the same seed always yields the same order
+func ShuffleWithSeed(slice []string, seed int64) {
+	Shuffle(slice, rand.New(rand.NewSource(seed)))
+}
+
 func Difference(A, B []string) []string {
 	// Create a map from list B
 	bMap := make(map[string]struct{})
@@ -29,3 +34,59 @@ func Difference(A, B []string) []string {
 
 	return diff
 }
+
+// CloseMatches returns up to three candidates that look like a misspelling of name, closest first
+func CloseMatches(name string, candidates []string) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+
+	name = strings.ToLower(name)
+	var matches []match
+	for _, candidate := range candidates {
+		lowered := strings.ToLower(candidate)
+		distance := levenshtein(name, lowered)
+		if distance <= max(2, len([]rune(name))/4) || (name != "" && strings.Contains(lowered, name)) {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+
+	closest := make([]string, 0, 3)
+	for _, m := range matches[:min(3, len(matches))] {
+		closest = append(closest, m.candidate)
+	}
+
+	return closest
+}
+
+// levenshtein counts the single character edits turning a into b
+func levenshtein(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current := make([]int, len(target)+1)
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(target)]
+}